@@ -6,24 +6,65 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
-const (
-	apiTimeout = 30 * time.Second
+// apiTimeout limits each request to the Monzo API. It is set by --timeout.
+var apiTimeout = 30 * time.Second
+
+var (
+	accountsIncludeClosed bool
+	accountsType          string
 )
 
+// accountTypeAliases maps short names accepted by --type to Monzo account types
+var accountTypeAliases = map[string]string{
+	"personal": "uk_retail",
+	"joint":    "uk_retail_joint",
+	"business": "uk_business",
+	"prepaid":  "uk_prepaid",
+}
+
 // Account represents a Monzo account
 type Account struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	Created     string `json:"created"`
-	Type        string `json:"type"`
-	Closed      bool   `json:"closed"`
+	ID            string         `json:"id"`
+	Description   string         `json:"description"`
+	Created       string         `json:"created"`
+	Type          string         `json:"type"`
+	Closed        bool           `json:"closed"`
+	Currency      string         `json:"currency,omitempty"`
+	CountryCode   string         `json:"country_code,omitempty"`
+	Owners        []AccountOwner `json:"owners,omitempty"`
+	AccountNumber string         `json:"account_number,omitempty"`
+	SortCode      string         `json:"sort_code,omitempty"`
+	BusinessID    string         `json:"business_id,omitempty"`
+}
+
+// AccountOwner represents a user who owns an account
+type AccountOwner struct {
+	UserID             string `json:"user_id"`
+	PreferredName      string `json:"preferred_name"`
+	PreferredFirstName string `json:"preferred_first_name"`
+}
+
+// Name returns a display name for the account: the names of its owners, or
+// the description for business accounts and accounts without owners
+func (a Account) Name() string {
+	if a.BusinessID != "" || len(a.Owners) == 0 {
+		return a.Description
+	}
+
+	names := make([]string, 0, len(a.Owners))
+	for _, owner := range a.Owners {
+		names = append(names, owner.PreferredName)
+	}
+	return strings.Join(names, " & ")
 }
 
 // AccountsResponse represents the response from the accounts endpoint
@@ -37,7 +78,11 @@ var accountsCmd = &cobra.Command{
 	Long: `List all accounts associated with the currently authenticated user.
 
 This command retrieves account information from the Monzo API and outputs
-the results in JSON format.
+the results in JSON format, including owners, sort codes and account numbers.
+
+Closed accounts are hidden unless --include-closed is given. --type limits the
+results to one kind of account: personal, joint, business, prepaid, or a Monzo
+account type such as uk_retail.
 
 You must be logged in before using this command. Use 'go-monzo login' first.`,
 	RunE: runAccounts,
@@ -45,21 +90,30 @@ You must be logged in before using this command. Use 'go-monzo login' first.`,
 
 func init() {
 	rootCmd.AddCommand(accountsCmd)
+
+	accountsCmd.Flags().BoolVar(&accountsIncludeClosed, "include-closed", false, "Include closed accounts")
+	accountsCmd.Flags().StringVar(&accountsType, "type", "", "Only list accounts of this type: personal, joint, business, prepaid or a Monzo account type")
+
+	_ = accountsCmd.RegisterFlagCompletionFunc("type", fixedCompletions("personal", "joint", "business", "prepaid"))
 }
 
 func runAccounts(cmd *cobra.Command, args []string) error {
 	// Load the stored token
-	token, err := loadToken()
+	token, err := loadToken(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
 	}
 
+	accountType := resolveAccountType(accountsType)
+
 	// Fetch accounts from the API
-	accounts, err := fetchAccounts(token.AccessToken)
+	accounts, err := fetchAccountsOfType(cmd.Context(), token.AccessToken, accountType)
 	if err != nil {
 		return fmt.Errorf("failed to fetch accounts: %w", err)
 	}
 
+	accounts.Accounts = filterAccounts(accounts.Accounts, accountType, accountsIncludeClosed)
+
 	// Output as JSON
 	output, err := json.MarshalIndent(accounts, "", "  ")
 	if err != nil {
@@ -70,7 +124,7 @@ func runAccounts(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func loadToken() (*TokenResponse, error) {
+func loadToken(ctx context.Context) (*TokenResponse, error) {
 	configDir, err := getConfigDir()
 	if err != nil {
 		return nil, err
@@ -101,9 +155,9 @@ func loadToken() (*TokenResponse, error) {
 			return nil, fmt.Errorf("token expired: please set MONZO_CLIENT_ID and MONZO_CLIENT_SECRET environment variables, add them to config file (~/.go-monzo/config.json), or run 'go-monzo login' again")
 		}
 
-		fmt.Println("Token expired, refreshing...")
+		fmt.Fprintln(os.Stderr, "Token expired, refreshing...")
 
-		newToken, err := RefreshToken(clientID, clientSecret, storedToken.RefreshToken)
+		newToken, err := RefreshToken(ctx, clientID, clientSecret, storedToken.RefreshToken)
 		if err != nil {
 			return nil, fmt.Errorf("failed to refresh token: %w", err)
 		}
@@ -113,25 +167,60 @@ func loadToken() (*TokenResponse, error) {
 			return nil, fmt.Errorf("failed to save refreshed token: %w", err)
 		}
 
-		fmt.Println("Token refreshed successfully!")
+		fmt.Fprintln(os.Stderr, "Token refreshed successfully!")
 		return newToken, nil
 	}
 
 	return &storedToken.TokenResponse, nil
 }
 
-func fetchAccounts(accessToken string) (*AccountsResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+// resolveAccountType returns the Monzo account type for a --type value
+func resolveAccountType(value string) string {
+	if accountType, ok := accountTypeAliases[strings.ToLower(value)]; ok {
+		return accountType
+	}
+	return value
+}
+
+// filterAccounts returns the accounts of a type, or of every type if
+// accountType is empty, leaving out closed accounts unless includeClosed is set
+func filterAccounts(accounts []Account, accountType string, includeClosed bool) []Account {
+	filtered := make([]Account, 0, len(accounts))
+	for _, account := range accounts {
+		if account.Closed && !includeClosed {
+			continue
+		}
+		if accountType != "" && account.Type != accountType {
+			continue
+		}
+		filtered = append(filtered, account)
+	}
+	return filtered
+}
+
+func fetchAccounts(ctx context.Context, accessToken string) (*AccountsResponse, error) {
+	return fetchAccountsOfType(ctx, accessToken, "")
+}
+
+// fetchAccountsOfType fetches the accounts of a Monzo account type, such as
+// uk_business, or every account if accountType is empty
+func fetchAccountsOfType(ctx context.Context, accessToken, accountType string) (*AccountsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", monzoAPIBaseURL+"/accounts", nil)
+	reqURL := apiBaseURL + "/accounts"
+	if accountType != "" {
+		reqURL += "?account_type=" + url.QueryEscape(accountType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestFetchAccountsOfType(t *testing.T) {
+	useMockAPI(t)
+
+	accounts, err := fetchAccountsOfType(context.Background(), monzotest.AccessToken, resolveAccountType("business"))
+	if err != nil {
+		t.Fatalf("Failed to fetch accounts: %v", err)
+	}
+	if len(accounts.Accounts) != 1 || accounts.Accounts[0].ID != monzotest.BusinessAccountID {
+		t.Fatalf("Expected only the business account, got %+v", accounts.Accounts)
+	}
+
+	business := accounts.Accounts[0]
+	if business.SortCode == "" || business.AccountNumber == "" || business.BusinessID == "" {
+		t.Errorf("Expected bank details and business ID, got %+v", business)
+	}
+	if business.Name() != business.Description {
+		t.Errorf("Expected business accounts to be named by description, got %q", business.Name())
+	}
+}
+
+func TestFilterAccounts(t *testing.T) {
+	accounts := []Account{
+		{ID: "acc_1", Type: "uk_retail", Owners: []AccountOwner{{PreferredName: "Alex"}}},
+		{ID: "acc_2", Type: "uk_retail_joint", Owners: []AccountOwner{{PreferredName: "Alex"}, {PreferredName: "Sam"}}},
+		{ID: "acc_3", Type: "uk_retail", Closed: true},
+	}
+
+	if open := filterAccounts(accounts, "", false); len(open) != 2 {
+		t.Errorf("Expected 2 open accounts, got %d", len(open))
+	}
+	if all := filterAccounts(accounts, "", true); len(all) != 3 {
+		t.Errorf("Expected 3 accounts including closed, got %d", len(all))
+	}
+
+	joint := filterAccounts(accounts, resolveAccountType("joint"), false)
+	if len(joint) != 1 || joint[0].Name() != "Alex & Sam" {
+		t.Errorf("Expected the joint account named by its owners, got %+v", joint)
+	}
+}
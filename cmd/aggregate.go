@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxConcurrentFetches limits how many accounts are fetched from the API at
+// once when aggregating across accounts
+const maxConcurrentFetches = 4
+
+// CurrencyTotal represents an amount summed across transactions or accounts
+type CurrencyTotal struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+	Count    int    `json:"count"`
+}
+
+// openAccounts fetches the user's accounts that haven't been closed
+func openAccounts(ctx context.Context, accessToken string) ([]Account, error) {
+	accounts, err := fetchAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	open := filterAccounts(accounts.Accounts, "", false)
+	if len(open) == 0 {
+		return nil, fmt.Errorf("no open accounts found")
+	}
+	return open, nil
+}
+
+// forEachAccount calls fetch for every account concurrently, at most
+// maxConcurrentFetches at a time. fetch is given the account's index so it can
+// store its result in order. The first error cancels the remaining fetches
+// and is returned.
+func forEachAccount(ctx context.Context, accounts []Account, fetch func(ctx context.Context, i int, account Account) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	limit := make(chan struct{}, maxConcurrentFetches)
+
+	for i, account := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case limit <- struct{}{}:
+				defer func() { <-limit }()
+			case <-ctx.Done():
+				return
+			}
+
+			if err := fetch(ctx, i, account); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("account %s: %w", account.ID, err)
+					cancel()
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// addCurrencyTotal adds an amount to the total for its currency
+func addCurrencyTotal(totals map[string]*CurrencyTotal, currency string, amount int64) {
+	total, ok := totals[currency]
+	if !ok {
+		total = &CurrencyTotal{Currency: currency}
+		totals[currency] = total
+	}
+	total.Amount += amount
+	total.Count++
+}
+
+// sortedCurrencyTotals returns totals ordered by currency code
+func sortedCurrencyTotals(totals map[string]*CurrencyTotal) []CurrencyTotal {
+	sorted := make([]CurrencyTotal, 0, len(totals))
+	for _, total := range totals {
+		sorted = append(sorted, *total)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Currency < sorted[j].Currency })
+	return sorted
+}
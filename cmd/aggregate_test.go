@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestForEachAccountBoundsConcurrency(t *testing.T) {
+	accounts := make([]Account, 10)
+	for i := range accounts {
+		accounts[i] = Account{ID: fmt.Sprintf("acc_%d", i)}
+	}
+
+	var running, peak int32
+	results := make([]string, len(accounts))
+	err := forEachAccount(context.Background(), accounts, func(ctx context.Context, i int, account Account) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		results[i] = account.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if peak > maxConcurrentFetches {
+		t.Errorf("Expected at most %d concurrent fetches, got %d", maxConcurrentFetches, peak)
+	}
+	for i, id := range results {
+		if id != accounts[i].ID {
+			t.Errorf("Expected result %d to be %s, got %s", i, accounts[i].ID, id)
+		}
+	}
+}
+
+func TestForEachAccountReturnsFirstError(t *testing.T) {
+	accounts := []Account{{ID: "acc_1"}, {ID: "acc_2"}}
+
+	err := forEachAccount(context.Background(), accounts, func(ctx context.Context, i int, account Account) error {
+		if account.ID == "acc_2" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "account acc_2: boom" {
+		t.Errorf("Expected the failing account's error, got %v", err)
+	}
+}
+
+func TestFetchAllBalances(t *testing.T) {
+	useMockAPI(t)
+
+	balances, err := fetchAllBalances(context.Background(), monzotest.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to fetch balances: %v", err)
+	}
+
+	if len(balances.Accounts) != 3 {
+		t.Fatalf("Expected the 3 open mock accounts, got %d", len(balances.Accounts))
+	}
+	if len(balances.Totals) != 1 || balances.Totals[0].Currency != "GBP" {
+		t.Fatalf("Expected a single GBP total, got %+v", balances.Totals)
+	}
+
+	var sum int64
+	for _, balance := range balances.Accounts {
+		sum += balance.Balance
+	}
+	if balances.Totals[0].Balance != sum {
+		t.Errorf("Expected total balance %d, got %d", sum, balances.Totals[0].Balance)
+	}
+}
+
+func TestAggregateTransactions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	accounts := []Account{{ID: "acc_1"}, {ID: "acc_2"}}
+	perAccount := [][]Transaction{
+		{
+			{ID: "tx_1", Created: "2024-01-01T10:00:00Z", Amount: -1000, Currency: "GBP"},
+			{ID: "tx_3", Created: "2024-01-03T10:00:00Z", Amount: -500, Currency: "GBP", DeclineReason: "INSUFFICIENT_FUNDS"},
+		},
+		{
+			{ID: "tx_2", Created: "2024-01-02T10:00:00Z", Amount: 2000, Currency: "GBP"},
+			{ID: "tx_4", Created: "2024-01-04T10:00:00Z", Amount: -300, Currency: "EUR"},
+		},
+	}
+
+	response, err := aggregateTransactions(accounts, perAccount, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, tx := range response.Transactions {
+		ids = append(ids, tx.ID)
+	}
+	if fmt.Sprint(ids) != "[tx_1 tx_2 tx_3 tx_4]" {
+		t.Errorf("Expected transactions merged in date order, got %v", ids)
+	}
+	if response.Transactions[1].AccountID != "acc_2" {
+		t.Errorf("Expected transactions to be labelled with their account, got %q", response.Transactions[1].AccountID)
+	}
+
+	expected := []CurrencyTotal{{Currency: "EUR", Amount: -300, Count: 1}, {Currency: "GBP", Amount: 1000, Count: 2}}
+	if fmt.Sprint(response.Totals) != fmt.Sprint(expected) {
+		t.Errorf("Expected totals %v, got %v", expected, response.Totals)
+	}
+	if len(response.Accounts[0].Totals) != 1 || response.Accounts[0].Totals[0].Amount != -1000 {
+		t.Errorf("Expected declined transactions to be left out of account totals, got %+v", response.Accounts[0].Totals)
+	}
+}
@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// backupFormatVersion is the version of the archive layout written by
+	// backup. Readers reject archives from newer versions.
+	backupFormatVersion = 1
+
+	backupManifestFile = "manifest.json"
+
+	// historyWindow is how far back Monzo returns transactions once more than
+	// five minutes have passed since login
+	historyWindow = 90 * 24 * time.Hour
+)
+
+var backupFile string
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	FormatVersion int          `json:"format_version"`
+	Created       time.Time    `json:"created"`
+	Files         []BackupFile `json:"files"`
+}
+
+// BackupFile describes a JSON lines file in a backup archive
+type BackupFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// BackupAttachment represents the metadata of a transaction attachment
+type BackupAttachment struct {
+	AccountID     string `json:"account_id"`
+	TransactionID string `json:"transaction_id"`
+	Attachment
+}
+
+// Backup represents the data held in a backup archive
+type Backup struct {
+	Manifest     BackupManifest
+	Accounts     []Account
+	Balances     []AccountBalance
+	Pots         []Pot
+	Transactions []Transaction
+	Attachments  []BackupAttachment
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Save all account data to an archive",
+	Long: `Save every account, balance, pot and transaction, with merchants and
+attachment metadata, to a portable archive.
+
+The archive is a gzipped tar of JSON lines files (accounts.jsonl,
+balances.jsonl, pots.jsonl, transactions.jsonl and attachments.jsonl) with a
+manifest.json recording the format version and the SHA-256 checksum of each
+file. --file defaults to go-monzo-backup-<date>.tar.gz in the current directory.
+
+Monzo only returns transactions older than 90 days within five minutes of
+logging in, so run 'go-monzo login --backup' to capture your full history.
+
+Use 'go-monzo backup verify' to check an archive, 'go-monzo backup restore' to
+load its transactions into the local cache for --offline commands, and
+'go-monzo transactions --archive' to read transactions from it directly.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	Args: cobra.NoArgs,
+	RunE: runBackup,
+}
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check the checksums of a backup archive",
+	Long: `Check that a backup archive is complete and that every file matches the
+checksum recorded in its manifest, and summarise its contents.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackupVerify,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Load a backup archive into the local transaction cache",
+	Long: `Load the transactions in a backup archive into the local cache in
+~/.go-monzo/cache, so commands run with --offline can use them. Cached
+transactions that are newer than the archive are kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackupRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupVerifyCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	backupCmd.Flags().StringVarP(&backupFile, "file", "f", "", "Path of the archive to write")
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	return createBackup(cmd.Context(), token.AccessToken, backupFile)
+}
+
+func runBackupVerify(cmd *cobra.Command, args []string) error {
+	backup, err := readBackup(args[0])
+	if err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
+	fmt.Printf("Archive %s is valid (format version %d, created %s)\n", args[0], backup.Manifest.FormatVersion, backup.Manifest.Created.Local().Format("2006-01-02 15:04:05"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tRECORDS")
+	for _, file := range backup.Manifest.Files {
+		fmt.Fprintf(w, "%s\t%d\n", file.Name, file.Records)
+	}
+	return w.Flush()
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	backup, err := readBackup(args[0])
+	if err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+
+	byAccount := make(map[string][]Transaction)
+	for _, tx := range backup.Transactions {
+		byAccount[tx.AccountID] = append(byAccount[tx.AccountID], tx)
+	}
+
+	for _, account := range backup.Accounts {
+		if err := updateTransactionCache(account.ID, byAccount[account.ID]); err != nil {
+			return fmt.Errorf("failed to restore transactions for %s: %w", account.ID, err)
+		}
+		fmt.Printf("Restored %d transactions for %s (%s)\n", len(byAccount[account.ID]), account.ID, account.Name())
+	}
+	return nil
+}
+
+// createBackup fetches all account data and writes it to an archive at path,
+// or to a dated file in the current directory if path is empty
+func createBackup(ctx context.Context, accessToken, path string) error {
+	if path == "" {
+		path = fmt.Sprintf("go-monzo-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	backup, err := fetchBackup(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := writeBackup(path, backup); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %d accounts, %d pots and %d transactions to %s\n", len(backup.Accounts), len(backup.Pots), len(backup.Transactions), path)
+
+	if oldest := oldestTransaction(backup.Transactions); !oldest.IsZero() && time.Since(oldest) < historyWindow-24*time.Hour {
+		fmt.Fprintln(os.Stderr, "Warning: no transactions older than 90 days were returned. Monzo only returns full history within five minutes of logging in; run 'go-monzo login --backup' to capture it.")
+	}
+	return nil
+}
+
+// fetchBackup fetches every account with its balance, pots and transactions.
+// Closed accounts that can no longer be read are skipped with a warning.
+func fetchBackup(ctx context.Context, accessToken string) (*Backup, error) {
+	accounts, err := fetchAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	perAccount := make([]*Backup, len(accounts.Accounts))
+	err = forEachAccount(ctx, accounts.Accounts, func(ctx context.Context, i int, account Account) error {
+		accountBackup, err := fetchAccountBackup(ctx, accessToken, account)
+		if err != nil {
+			if account.Closed && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping closed account %s: %v\n", account.ID, err)
+				return nil
+			}
+			return err
+		}
+		perAccount[i] = accountBackup
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up account: %w", err)
+	}
+
+	backup := &Backup{Accounts: accounts.Accounts}
+	for _, accountBackup := range perAccount {
+		if accountBackup == nil {
+			continue
+		}
+		backup.Balances = append(backup.Balances, accountBackup.Balances...)
+		backup.Pots = append(backup.Pots, accountBackup.Pots...)
+		for _, tx := range accountBackup.Transactions {
+			backup.Transactions = append(backup.Transactions, tx)
+			for _, attachment := range tx.Attachments {
+				backup.Attachments = append(backup.Attachments, BackupAttachment{AccountID: tx.AccountID, TransactionID: tx.ID, Attachment: attachment})
+			}
+		}
+	}
+	return backup, nil
+}
+
+// fetchAccountBackup fetches the balance, pots and full transaction history
+// of an account
+func fetchAccountBackup(ctx context.Context, accessToken string, account Account) (*Backup, error) {
+	balance, err := fetchBalance(ctx, accessToken, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	pots, err := fetchPots(ctx, accessToken, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pots: %w", err)
+	}
+
+	transactions, err := fetchTransactions(ctx, accessToken, account.ID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	backup := &Backup{
+		Balances:     []AccountBalance{{AccountID: account.ID, AccountName: account.Name(), AccountType: account.Type, BalanceResponse: *balance}},
+		Pots:         pots.Pots,
+		Transactions: transactions.Transactions,
+	}
+	for i := range backup.Pots {
+		backup.Pots[i].CurrentAccountID = account.ID
+	}
+	for i := range backup.Transactions {
+		backup.Transactions[i].AccountID = account.ID
+	}
+	return backup, nil
+}
+
+// oldestTransaction returns the creation time of the oldest transaction
+func oldestTransaction(transactions []Transaction) time.Time {
+	var oldest time.Time
+	for _, tx := range transactions {
+		if created := tx.CreatedTime(); !created.IsZero() && (oldest.IsZero() || created.Before(oldest)) {
+			oldest = created
+		}
+	}
+	return oldest
+}
+
+// writeBackup writes a backup archive to path. The archive is written to a
+// temporary file first so a failed backup never leaves a partial archive.
+func writeBackup(path string, backup *Backup) error {
+	files := []struct {
+		name    string
+		records interface{}
+	}{
+		{"accounts.jsonl", backup.Accounts},
+		{"balances.jsonl", backup.Balances},
+		{"pots.jsonl", backup.Pots},
+		{"transactions.jsonl", backup.Transactions},
+		{"attachments.jsonl", backup.Attachments},
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".go-monzo-backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	manifest := BackupManifest{FormatVersion: backupFormatVersion, Created: now.UTC()}
+	for _, file := range files {
+		data, count, err := encodeJSONLines(file.records)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		if err := writeTarFile(tw, file.name, data, now); err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, BackupFile{Name: file.name, Records: count, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeTarFile(tw, backupManifestFile, data, now); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save archive: %w", err)
+	}
+	return nil
+}
+
+// encodeJSONLines encodes each element of a slice as a line of JSON
+func encodeJSONLines(records interface{}) ([]byte, int, error) {
+	var items []json.RawMessage
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	for _, item := range items {
+		buf.Write(item)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), len(items), nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modified time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modified, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// readBackup reads a backup archive, checking its format version and the
+// checksum of every file listed in its manifest
+func readBackup(path string) (*Backup, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		contents[header.Name] = data
+	}
+
+	manifestData, ok := contents[backupManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("archive has no %s", backupManifestFile)
+	}
+
+	backup := &Backup{}
+	if err := json.Unmarshal(manifestData, &backup.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", backupManifestFile, err)
+	}
+	if backup.Manifest.FormatVersion < 1 || backup.Manifest.FormatVersion > backupFormatVersion {
+		return nil, fmt.Errorf("unsupported archive format version %d", backup.Manifest.FormatVersion)
+	}
+
+	targets := map[string]interface{}{
+		"accounts.jsonl":     &backup.Accounts,
+		"balances.jsonl":     &backup.Balances,
+		"pots.jsonl":         &backup.Pots,
+		"transactions.jsonl": &backup.Transactions,
+		"attachments.jsonl":  &backup.Attachments,
+	}
+
+	for _, file := range backup.Manifest.Files {
+		data, ok := contents[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", file.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != file.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s: the archive is corrupt", file.Name)
+		}
+
+		target, ok := targets[file.Name]
+		if !ok {
+			continue
+		}
+		count, err := decodeJSONLines(data, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file.Name, err)
+		}
+		if count != file.Records {
+			return nil, fmt.Errorf("%s has %d records, expected %d", file.Name, count, file.Records)
+		}
+	}
+
+	sort.SliceStable(backup.Transactions, func(i, j int) bool {
+		return backup.Transactions[i].CreatedTime().Before(backup.Transactions[j].CreatedTime())
+	})
+	return backup, nil
+}
+
+// decodeJSONLines decodes JSON lines into the slice pointed to by target and
+// returns the number of records
+func decodeJSONLines(data []byte, target interface{}) (int, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if count > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(line)
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	buf.WriteByte(']')
+
+	return count, json.Unmarshal(buf.Bytes(), target)
+}
+
+// archiveTransactions returns the transactions of an account in a backup
+func (b *Backup) archiveTransactions(accountID string) []Transaction {
+	transactions := []Transaction{}
+	for _, tx := range b.Transactions {
+		if tx.AccountID == accountID {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions
+}
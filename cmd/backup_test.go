@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	mock := useMockAPI(t)
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+
+	backup, err := fetchBackup(context.Background(), monzotest.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to fetch backup: %v", err)
+	}
+	if err := writeBackup(path, backup); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	restored, err := readBackup(path)
+	if err != nil {
+		t.Fatalf("Failed to read backup: %v", err)
+	}
+
+	if len(restored.Accounts) != len(backup.Accounts) || len(restored.Transactions) != len(backup.Transactions) {
+		t.Errorf("Expected %d accounts and %d transactions, got %d and %d",
+			len(backup.Accounts), len(backup.Transactions), len(restored.Accounts), len(restored.Transactions))
+	}
+	if len(restored.Attachments) == 0 {
+		t.Error("Expected attachment metadata to be backed up")
+	}
+
+	// Every page of the personal account's history is included
+	all := 0
+	for _, tx := range mock.Transactions {
+		if tx.AccountID == monzotest.PersonalAccountID {
+			all++
+		}
+	}
+	personal := restored.archiveTransactions(monzotest.PersonalAccountID)
+	if len(personal) != all || len(personal) <= transactionsPageSize {
+		t.Errorf("Expected all %d personal transactions, got %d", all, len(personal))
+	}
+	if personal[0].Merchant == nil && personal[1].Merchant == nil {
+		t.Error("Expected merchants to be expanded")
+	}
+}
+
+func TestReadBackupDetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.tar.gz")
+	backup := &Backup{
+		Accounts:     []Account{{ID: "acc_1"}},
+		Transactions: []Transaction{{ID: "tx_1", AccountID: "acc_1", Amount: -100}},
+	}
+	if err := writeBackup(path, backup); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	// Rewrite the archive with an altered transaction
+	corrupted := filepath.Join(dir, "corrupted.tar.gz")
+	rewriteArchive(t, path, corrupted, func(name string, data []byte) []byte {
+		if name == "transactions.jsonl" {
+			return bytes.Replace(data, []byte("-100"), []byte("-999"), 1)
+		}
+		return data
+	})
+
+	if _, err := readBackup(corrupted); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum error, got %v", err)
+	}
+}
+
+// rewriteArchive copies a tar.gz archive, passing each file through edit
+func rewriteArchive(t *testing.T, from, to string, edit func(name string, data []byte) []byte) {
+	t.Helper()
+
+	in, err := os.Open(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	gzIn, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.Create(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	gzOut := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzOut)
+
+	tr := tar.NewReader(gzIn)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		data = edit(header.Name, data)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzOut.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
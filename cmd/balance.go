@@ -8,11 +8,17 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 )
 
-var accountID string
+var (
+	accountID     string
+	balanceAll    bool
+	balanceOutput string
+)
 
 // BalanceResponse represents the response from the balance endpoint
 type BalanceResponse struct {
@@ -23,13 +29,32 @@ type BalanceResponse struct {
 	SpendToday                      int64  `json:"spend_today"`
 }
 
+// AccountBalance represents the balance of one of several accounts
+type AccountBalance struct {
+	AccountID   string `json:"account_id"`
+	AccountName string `json:"account_name"`
+	AccountType string `json:"account_type"`
+	BalanceResponse
+}
+
+// BalancesResponse represents the balances of every open account, with
+// totals for each currency
+type BalancesResponse struct {
+	Accounts []AccountBalance  `json:"accounts"`
+	Totals   []BalanceResponse `json:"totals"`
+}
+
 var balanceCmd = &cobra.Command{
 	Use:   "balance",
 	Short: "Get the balance of an account",
 	Long: `Get the balance of a Monzo account.
 
 This command retrieves the current balance information from the Monzo API
-and outputs the results in JSON format.
+and outputs the results in JSON format, or as a table with -o table.
+
+With --all, the balances of every open account are fetched and shown
+together, with totals for each currency. Balances in different currencies
+are never added together.
 
 You must be logged in before using this command. Use 'go-monzo login' first.
 You can obtain your account ID using the 'go-monzo accounts' command.`,
@@ -39,26 +64,50 @@ You can obtain your account ID using the 'go-monzo accounts' command.`,
 func init() {
 	rootCmd.AddCommand(balanceCmd)
 
-	balanceCmd.Flags().StringVar(&accountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	balanceCmd.PersistentFlags().StringVar(&accountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+
+	balanceCmd.Flags().BoolVar(&balanceAll, "all", false, "Show the balances of all open accounts with totals")
+	balanceCmd.Flags().StringVarP(&balanceOutput, "output", "o", "json", "Output format: table or json")
+
+	_ = balanceCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = balanceCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
 }
 
 func runBalance(cmd *cobra.Command, args []string) error {
-	if accountID == "" {
+	if accountID == "" && !balanceAll {
 		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
 	}
 
+	if err := validateOutputFormat(balanceOutput); err != nil {
+		return err
+	}
+
 	// Load the stored token
-	token, err := loadToken()
+	token, err := loadToken(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
 	}
 
+	if balanceAll {
+		balances, err := fetchAllBalances(cmd.Context(), token.AccessToken)
+		if err != nil {
+			return err
+		}
+		return printBalances(balances)
+	}
+
 	// Fetch balance from the API
-	balance, err := fetchBalance(token.AccessToken, accountID)
+	balance, err := fetchBalance(cmd.Context(), token.AccessToken, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch balance: %w", err)
 	}
 
+	if balanceOutput == "table" {
+		return printBalances(&BalancesResponse{
+			Accounts: []AccountBalance{{AccountID: accountID, BalanceResponse: *balance}},
+		})
+	}
+
 	// Output as JSON
 	output, err := json.MarshalIndent(balance, "", "  ")
 	if err != nil {
@@ -69,11 +118,95 @@ func runBalance(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func fetchBalance(accessToken, accountID string) (*BalanceResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+// fetchAllBalances fetches the balance of every open account and totals them
+// by currency
+func fetchAllBalances(ctx context.Context, accessToken string) (*BalancesResponse, error) {
+	accounts, err := openAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]AccountBalance, len(accounts))
+	err = forEachAccount(ctx, accounts, func(ctx context.Context, i int, account Account) error {
+		balance, err := fetchBalance(ctx, accessToken, account.ID)
+		if err != nil {
+			return err
+		}
+		balances[i] = AccountBalance{
+			AccountID:       account.ID,
+			AccountName:     account.Name(),
+			AccountType:     account.Type,
+			BalanceResponse: *balance,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	return &BalancesResponse{Accounts: balances, Totals: totalBalances(balances)}, nil
+}
+
+// totalBalances sums balances by currency
+func totalBalances(balances []AccountBalance) []BalanceResponse {
+	totals := make(map[string]*BalanceResponse)
+	var currencies []string
+	for _, balance := range balances {
+		total, ok := totals[balance.Currency]
+		if !ok {
+			total = &BalanceResponse{Currency: balance.Currency}
+			totals[balance.Currency] = total
+			currencies = append(currencies, balance.Currency)
+		}
+		total.Balance += balance.Balance
+		total.TotalBalance += balance.TotalBalance
+		total.BalanceIncludingFlexibleSavings += balance.BalanceIncludingFlexibleSavings
+		total.SpendToday += balance.SpendToday
+	}
+
+	sort.Strings(currencies)
+	sorted := make([]BalanceResponse, 0, len(currencies))
+	for _, currency := range currencies {
+		sorted = append(sorted, *totals[currency])
+	}
+	return sorted
+}
+
+// printBalances outputs balances in the selected format
+func printBalances(balances *BalancesResponse) error {
+	if balanceOutput == "json" {
+		output, err := json.MarshalIndent(balances, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal balances: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tNAME\tTYPE\tBALANCE\tWITH POTS\tSPENT TODAY")
+	for _, balance := range balances.Accounts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			balance.AccountID, balance.AccountName, balance.AccountType,
+			NewMoney(balance.Balance, balance.Currency).String(),
+			NewMoney(balance.TotalBalance, balance.Currency).String(),
+			NewMoney(-balance.SpendToday, balance.Currency).String())
+	}
+	for _, total := range balances.Totals {
+		fmt.Fprintf(w, "TOTAL\t\t%s\t%s\t%s\t%s\n",
+			total.Currency,
+			NewMoney(total.Balance, total.Currency).String(),
+			NewMoney(total.TotalBalance, total.Currency).String(),
+			NewMoney(-total.SpendToday, total.Currency).String())
+	}
+	return w.Flush()
+}
+
+func fetchBalance(ctx context.Context, accessToken, accountID string) (*BalanceResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
 	defer cancel()
 
-	reqURL := fmt.Sprintf("%s/balance?account_id=%s", monzoAPIBaseURL, url.QueryEscape(accountID))
+	reqURL := fmt.Sprintf("%s/balance?account_id=%s", apiBaseURL, url.QueryEscape(accountID))
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -81,7 +214,7 @@ func fetchBalance(accessToken, accountID string) (*BalanceResponse, error) {
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFrom     string
+	historyTo       string
+	historyInterval string
+	historyOutput   string
+)
+
+// BalanceHistory represents end-of-period balances for an account
+type BalanceHistory struct {
+	AccountID string         `json:"account_id"`
+	Currency  string         `json:"currency"`
+	Interval  string         `json:"interval"`
+	Points    []BalancePoint `json:"points"`
+}
+
+// BalancePoint represents the balance at the end of a period
+type BalancePoint struct {
+	Date    string `json:"date"` // Last day of the period
+	Balance int64  `json:"balance"`
+}
+
+var balanceHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the balance at the end of each day, week or month",
+	Long: `Show the balance of a Monzo account at the end of each day, week or month.
+
+Balances are reconstructed from the running balance recorded on transactions.
+Where that isn't available they are calculated backwards from the current
+balance by undoing later transactions.
+
+Dates use the YYYY-MM-DD format. --from defaults to the start of the current
+month and --to defaults to today. The JSON output is suitable for charting.`,
+	RunE: runBalanceHistory,
+}
+
+func init() {
+	balanceCmd.AddCommand(balanceHistoryCmd)
+
+	balanceHistoryCmd.Flags().StringVar(&historyFrom, "from", "", "Start date of the history (YYYY-MM-DD)")
+	balanceHistoryCmd.Flags().StringVar(&historyTo, "to", "", "End date of the history, inclusive (YYYY-MM-DD)")
+	balanceHistoryCmd.Flags().StringVar(&historyInterval, "interval", "day", "Interval between balances: day, week or month")
+	balanceHistoryCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "Output format: table or json")
+
+	_ = balanceHistoryCmd.RegisterFlagCompletionFunc("interval", fixedCompletions("day", "week", "month"))
+	_ = balanceHistoryCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+}
+
+func runBalanceHistory(cmd *cobra.Command, args []string) error {
+	if accountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if _, err := intervalStep(historyInterval); err != nil {
+		return err
+	}
+
+	if err := validateOutputFormat(historyOutput); err != nil {
+		return err
+	}
+
+	from, to, err := parseDateRange(historyFrom, historyTo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	balance, err := fetchBalance(cmd.Context(), token.AccessToken, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	// Transactions after the range are needed to calculate back from today
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, accountID, from, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	history, err := buildBalanceHistory(transactions.Transactions, balance.Balance, from, to, historyInterval)
+	if err != nil {
+		return err
+	}
+	history.AccountID = accountID
+	history.Currency = balance.Currency
+
+	if historyOutput == "json" {
+		output, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal balance history: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tBALANCE")
+	values := make([]int64, 0, len(history.Points))
+	for _, point := range history.Points {
+		fmt.Fprintf(w, "%s\t%s\n", point.Date, NewMoney(point.Balance, history.Currency).String())
+		values = append(values, point.Balance)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", sparkline(values))
+	return nil
+}
+
+// buildBalanceHistory reconstructs the balance at the end of each interval in
+// [from, to). currentBalance is the balance now, after every transaction.
+func buildBalanceHistory(transactions []Transaction, currentBalance int64, from, to time.Time, interval string) (*BalanceHistory, error) {
+	step, err := intervalStep(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending transactions are included, as the current balance already
+	// reflects them
+	var included []Transaction
+	hasRunningBalance := false
+	for _, tx := range transactions {
+		if tx.IsDeclined() {
+			continue
+		}
+		included = append(included, tx)
+		if tx.AccountBalance != 0 {
+			hasRunningBalance = true
+		}
+	}
+
+	sort.Slice(included, func(i, j int) bool {
+		return included[i].CreatedTime().Before(included[j].CreatedTime())
+	})
+
+	history := &BalanceHistory{Interval: interval, Points: []BalancePoint{}}
+
+	for start := from; start.Before(to); start = step(start) {
+		end := step(start)
+		if end.After(to) {
+			end = to
+		}
+		history.Points = append(history.Points, BalancePoint{
+			Date:    end.AddDate(0, 0, -1).Format(dateLayout),
+			Balance: balanceAt(included, currentBalance, end, hasRunningBalance),
+		})
+	}
+
+	return history, nil
+}
+
+// balanceAt returns the balance just before t. transactions must be sorted
+// oldest first and exclude declines. With useRunningBalance the balance is
+// read from the running balance recorded on transactions, otherwise it is
+// calculated back from currentBalance.
+func balanceAt(transactions []Transaction, currentBalance int64, t time.Time, useRunningBalance bool) int64 {
+	// Index of the first transaction at or after t
+	i := sort.Search(len(transactions), func(i int) bool {
+		return !transactions[i].CreatedTime().Before(t)
+	})
+
+	if useRunningBalance {
+		if i == 0 {
+			// Before the first transaction, undo it from its running balance
+			return transactions[0].AccountBalance - transactions[0].Amount
+		}
+		return transactions[i-1].AccountBalance
+	}
+
+	// Undo every transaction made since t
+	balance := currentBalance
+	for _, tx := range transactions[i:] {
+		balance -= tx.Amount
+	}
+	return balance
+}
+
+// intervalStep returns a function advancing a time by one interval
+func intervalStep(interval string) (func(time.Time) time.Time, error) {
+	switch interval {
+	case "day":
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, nil
+	case "week":
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, nil
+	case "month":
+		return func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, nil
+	default:
+		return nil, fmt.Errorf("invalid --interval value %q: must be day, week or month", interval)
+	}
+}
+
+// sparkline renders the values as a line of block characters
+func sparkline(values []int64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	blocks := []rune("▁▂▃▄▅▆▇█")
+
+	lowest, highest := values[0], values[0]
+	for _, v := range values {
+		if v < lowest {
+			lowest = v
+		}
+		if v > highest {
+			highest = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := len(blocks) / 2
+		if highest > lowest {
+			level = int(float64(v-lowest) / float64(highest-lowest) * float64(len(blocks)-1))
+		}
+		b.WriteRune(blocks[level])
+	}
+	return b.String()
+}
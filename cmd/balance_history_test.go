@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBalanceAt(t *testing.T) {
+	// Opening balance 10000, then -2000, +5000 and a pending -1500
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-03-01T10:00:00Z", Amount: -2000, AccountBalance: 8000, Settled: "2024-03-02T10:00:00Z"},
+		{ID: "tx_2", Created: "2024-03-03T10:00:00Z", Amount: 5000, AccountBalance: 13000, Settled: "2024-03-03T10:00:00Z"},
+		{ID: "tx_3", Created: "2024-03-05T10:00:00Z", Amount: -1500, AccountBalance: 11500},
+	}
+	const currentBalance = 11500
+
+	tests := []struct {
+		name              string
+		at                time.Time
+		useRunningBalance bool
+		expected          int64
+	}{
+		{"running balance before the first transaction", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true, 10000},
+		{"running balance between transactions", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), true, 8000},
+		{"running balance before a pending debit", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), true, 13000},
+		{"running balance after a pending debit", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), true, 11500},
+		{"calculated before the first transaction", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false, 10000},
+		{"calculated between transactions", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), false, 13000},
+		{"calculated before a pending debit", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false, 13000},
+		{"calculated after a pending debit", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), false, 11500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if balance := balanceAt(transactions, currentBalance, tt.at, tt.useRunningBalance); balance != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, balance)
+			}
+		})
+	}
+}
+
+func TestBuildBalanceHistory(t *testing.T) {
+	transactions := []Transaction{
+		{ID: "tx_2", Created: "2024-03-03T10:00:00Z", Amount: 5000},
+		{ID: "tx_1", Created: "2024-03-01T10:00:00Z", Amount: -2000},
+		// Declined payments don't change the balance
+		{ID: "tx_declined", Created: "2024-03-02T10:00:00Z", Amount: -9900, DeclineReason: "INSUFFICIENT_FUNDS"},
+		// A pending debit is already taken from the current balance
+		{ID: "tx_pending", Created: "2024-03-04T10:00:00Z", Amount: -1500},
+	}
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	history, err := buildBalanceHistory(transactions, 11500, from, to, "day")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []BalancePoint{
+		{Date: "2024-03-01", Balance: 8000},
+		{Date: "2024-03-02", Balance: 8000},
+		{Date: "2024-03-03", Balance: 13000},
+		{Date: "2024-03-04", Balance: 11500},
+	}
+	if len(history.Points) != len(expected) {
+		t.Fatalf("Expected %d points, got %+v", len(expected), history.Points)
+	}
+	for i, point := range history.Points {
+		if point != expected[i] {
+			t.Errorf("Point %d: expected %+v, got %+v", i, expected[i], point)
+		}
+	}
+
+	if _, err := buildBalanceHistory(transactions, 11500, from, to, "year"); err == nil {
+		t.Error("Expected an error for an invalid interval")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values   []int64
+		expected string
+	}{
+		{nil, ""},
+		{[]int64{5, 5, 5}, "▅▅▅"},
+		{[]int64{0, 700, 350, 100}, "▁█▄▂"},
+	}
+
+	for _, tt := range tests {
+		if line := sparkline(tt.values); line != tt.expected {
+			t.Errorf("sparkline(%v): expected %q, got %q", tt.values, tt.expected, line)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const budgetsFile = "budgets.json"
+
+var (
+	budgetAccountID string
+	budgetCurrency  string
+	budgetOutput    string
+	budgetPeriod    string
+)
+
+// Budget represents a monthly spending limit for a Monzo category
+type Budget struct {
+	Category string `json:"category"`
+	Limit    int64  `json:"limit"` // Limit in minor units
+	Currency string `json:"currency"`
+}
+
+// BudgetConfig represents the budgets stored in ~/.go-monzo/budgets.json
+type BudgetConfig struct {
+	Budgets []Budget `json:"budgets"`
+}
+
+// BudgetStatus represents the spending against a budget for the current period
+type BudgetStatus struct {
+	Budget
+	Spent     int64 `json:"spent"`
+	Remaining int64 `json:"remaining"`
+	Projected int64 `json:"projected"`
+	Over      bool  `json:"over"`
+}
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Manage monthly budgets per category",
+	Long: `Manage monthly spending budgets for Monzo categories.
+
+Budgets are stored in ~/.go-monzo/budgets.json. Categories use the Monzo
+category names, such as eating_out, groceries or entertainment.`,
+}
+
+var budgetSetCmd = &cobra.Command{
+	Use:   "set <category> <amount>",
+	Short: "Set the monthly limit for a category",
+	Long: `Set the monthly spending limit for a Monzo category.
+
+The amount is given in major units, optionally with a currency, e.g.
+'go-monzo budget set eating_out £200' or 'go-monzo budget set eating_out 200'
+both set a limit of £200.00 for eating out. Amounts without a currency use
+--currency.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runBudgetSet,
+}
+
+var budgetRemoveCmd = &cobra.Command{
+	Use:               "remove <category>",
+	Short:             "Remove the budget for a category",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeBudgetCategories,
+	RunE:              runBudgetRemove,
+}
+
+var budgetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List budgets",
+	RunE:  runBudgetList,
+}
+
+var budgetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show spending against budgets for the current month",
+	Long: `Show spending against each budget for the current month, including the
+remaining headroom and the spend projected by the end of the month. Only
+spending in a budget's own currency counts towards it.
+
+With --period pay-cycle, budgets run from your last payday to the next one
+instead of by calendar month. See 'go-monzo payday' for how paydays are
+detected.
+
+The command exits with a non-zero status if any budget has been exceeded, so
+it can be used to gate scripts and alerts.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runBudgetStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	budgetCmd.AddCommand(budgetSetCmd)
+	budgetCmd.AddCommand(budgetRemoveCmd)
+	budgetCmd.AddCommand(budgetListCmd)
+	budgetCmd.AddCommand(budgetStatusCmd)
+
+	budgetSetCmd.Flags().StringVar(&budgetCurrency, "currency", "GBP", "Currency of the budget when the amount doesn't specify one")
+
+	budgetStatusCmd.Flags().StringVar(&budgetAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	budgetStatusCmd.Flags().StringVarP(&budgetOutput, "output", "o", "table", "Output format: table or json")
+	budgetStatusCmd.Flags().StringVar(&budgetPeriod, "period", periodMonth, "Budget period: month or pay-cycle")
+
+	_ = budgetSetCmd.RegisterFlagCompletionFunc("currency", completeCurrencies)
+	_ = budgetStatusCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = budgetStatusCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+	_ = budgetStatusCmd.RegisterFlagCompletionFunc("period", fixedCompletions(periodMonth, periodPayCycle))
+}
+
+// completeBudgetCategories suggests the categories that have a budget
+func completeBudgetCategories(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	budgets, err := loadBudgets()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	categories := make([]string, 0, len(budgets.Budgets))
+	for _, budget := range budgets.Budgets {
+		categories = append(categories, budget.Category)
+	}
+	return categories, cobra.ShellCompDirectiveNoFileComp
+}
+
+// loadBudgets loads the budgets from ~/.go-monzo/budgets.json
+func loadBudgets() (*BudgetConfig, error) {
+	var budgets BudgetConfig
+	if err := loadStateFile(budgetsFile, &budgets); err != nil {
+		return nil, fmt.Errorf("failed to load budgets: %w", err)
+	}
+	return &budgets, nil
+}
+
+func runBudgetSet(cmd *cobra.Command, args []string) error {
+	category := args[0]
+
+	limit, err := ParseMoney(args[1], budgetCurrency)
+	if err != nil {
+		return err
+	}
+	if limit.Amount <= 0 {
+		return fmt.Errorf("budget limit must be greater than zero")
+	}
+
+	budgets, err := loadBudgets()
+	if err != nil {
+		return err
+	}
+
+	budget := Budget{Category: category, Limit: limit.Amount, Currency: limit.Currency}
+
+	replaced := false
+	for i := range budgets.Budgets {
+		if budgets.Budgets[i].Category == category {
+			budgets.Budgets[i] = budget
+			replaced = true
+		}
+	}
+	if !replaced {
+		budgets.Budgets = append(budgets.Budgets, budget)
+	}
+
+	sort.Slice(budgets.Budgets, func(i, j int) bool {
+		return budgets.Budgets[i].Category < budgets.Budgets[j].Category
+	})
+
+	if err := saveStateFile(budgetsFile, budgets); err != nil {
+		return fmt.Errorf("failed to save budgets: %w", err)
+	}
+
+	fmt.Printf("Budget for %s set to %s per month\n", category, NewMoney(budget.Limit, budget.Currency).String())
+	return nil
+}
+
+func runBudgetRemove(cmd *cobra.Command, args []string) error {
+	budgets, err := loadBudgets()
+	if err != nil {
+		return err
+	}
+
+	kept := budgets.Budgets[:0]
+	for _, budget := range budgets.Budgets {
+		if budget.Category != args[0] {
+			kept = append(kept, budget)
+		}
+	}
+
+	if len(kept) == len(budgets.Budgets) {
+		return fmt.Errorf("no budget set for category %s", args[0])
+	}
+	budgets.Budgets = kept
+
+	if err := saveStateFile(budgetsFile, budgets); err != nil {
+		return fmt.Errorf("failed to save budgets: %w", err)
+	}
+
+	fmt.Printf("Budget for %s removed\n", args[0])
+	return nil
+}
+
+func runBudgetList(cmd *cobra.Command, args []string) error {
+	budgets, err := loadBudgets()
+	if err != nil {
+		return err
+	}
+
+	if len(budgets.Budgets) == 0 {
+		fmt.Println("No budgets set. Use 'go-monzo budget set <category> <amount>' to add one.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tLIMIT")
+	for _, budget := range budgets.Budgets {
+		fmt.Fprintf(w, "%s\t%s\n", budget.Category, NewMoney(budget.Limit, budget.Currency).String())
+	}
+	return w.Flush()
+}
+
+func runBudgetStatus(cmd *cobra.Command, args []string) error {
+	if budgetAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(budgetOutput); err != nil {
+		return err
+	}
+
+	if err := validatePeriod(budgetPeriod); err != nil {
+		return err
+	}
+
+	budgets, err := loadBudgets()
+	if err != nil {
+		return err
+	}
+
+	if len(budgets.Budgets) == 0 {
+		return fmt.Errorf("no budgets set. Use 'go-monzo budget set <category> <amount>' to add one")
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+	if budgetPeriod == periodPayCycle {
+		cycle, err := resolvePayCycle(cmd.Context(), token.AccessToken, budgetAccountID, now)
+		if err != nil {
+			return err
+		}
+		from, to = cycle.Start, cycle.End
+	}
+
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, budgetAccountID, from, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	statuses := evaluateBudgets(budgets.Budgets, transactions.Transactions, from, to, now)
+
+	if budgetOutput == "json" {
+		output, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal budget status: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		printBudgetStatus(statuses, from, to)
+	}
+
+	var over []string
+	for _, status := range statuses {
+		if status.Over {
+			over = append(over, status.Category)
+		}
+	}
+
+	if len(over) > 0 {
+		// The error is the result rather than a usage problem
+		cmd.SilenceUsage = true
+		return fmt.Errorf("over budget: %s", strings.Join(over, ", "))
+	}
+
+	return nil
+}
+
+// evaluateBudgets compares spending in the period [from, to) with each budget,
+// projecting month-end spend from the rate of spending up to now. Only spending
+// in a budget's own currency counts towards it.
+func evaluateBudgets(budgets []Budget, transactions []Transaction, from, to, now time.Time) []BudgetStatus {
+	type spendingKey struct{ category, currency string }
+
+	spent := make(map[spendingKey]int64)
+	for _, tx := range transactions {
+		created := tx.CreatedTime()
+		if !tx.IsSpending() || created.Before(from) || !created.Before(to) {
+			continue
+		}
+		spent[spendingKey{tx.Category, tx.Currency}] += -tx.Amount
+	}
+
+	// Fraction of the period that has elapsed, used to project spending
+	elapsed := now.Sub(from).Seconds() / to.Sub(from).Seconds()
+	if elapsed > 1 {
+		elapsed = 1
+	}
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		total := spent[spendingKey{budget.Category, budget.Currency}]
+		status := BudgetStatus{
+			Budget:    budget,
+			Spent:     total,
+			Remaining: budget.Limit - total,
+			Projected: total,
+		}
+		if elapsed > 0 {
+			status.Projected = int64(float64(status.Spent) / elapsed)
+		}
+		status.Over = status.Spent > budget.Limit
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+func printBudgetStatus(statuses []BudgetStatus, from, to time.Time) {
+	fmt.Printf("Budgets from %s to %s\n\n", from.Format(dateLayout), to.AddDate(0, 0, -1).Format(dateLayout))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tLIMIT\tSPENT\tREMAINING\tPROJECTED\tSTATUS")
+	for _, status := range statuses {
+		state := "OK"
+		switch {
+		case status.Over:
+			state = "OVER"
+		case status.Projected > status.Limit:
+			state = "AT RISK"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			status.Category,
+			NewMoney(status.Limit, status.Currency).String(),
+			NewMoney(status.Spent, status.Currency).String(),
+			NewMoney(status.Remaining, status.Currency).String(),
+			NewMoney(status.Projected, status.Currency).String(),
+			state)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateBudgets(t *testing.T) {
+	from := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 4, 16, 0, 0, 0, 0, time.UTC) // Half way through the month
+
+	budgets := []Budget{
+		{Category: "eating_out", Limit: 20000, Currency: "GBP"},
+		{Category: "groceries", Limit: 30000, Currency: "GBP"},
+	}
+
+	transactions := []Transaction{
+		{Created: "2024-04-02T12:00:00Z", Amount: -15000, Currency: "GBP", Category: "eating_out", IncludeInSpending: true},
+		{Created: "2024-04-10T12:00:00Z", Amount: -7000, Currency: "GBP", Category: "eating_out", IncludeInSpending: true},
+		{Created: "2024-04-05T12:00:00Z", Amount: -5000, Currency: "GBP", Category: "groceries", IncludeInSpending: true},
+		{Created: "2024-03-30T12:00:00Z", Amount: -9000, Currency: "GBP", Category: "groceries", IncludeInSpending: true},
+		// Spending in another currency doesn't count towards a GBP budget
+		{Created: "2024-04-12T12:00:00Z", Amount: -40000, Currency: "EUR", Category: "groceries", IncludeInSpending: true},
+	}
+
+	statuses := evaluateBudgets(budgets, transactions, from, to, now)
+
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 statuses, got %d", len(statuses))
+	}
+
+	eatingOut := statuses[0]
+	if eatingOut.Spent != 22000 || eatingOut.Remaining != -2000 || !eatingOut.Over {
+		t.Errorf("Unexpected eating_out status: %+v", eatingOut)
+	}
+
+	if eatingOut.Projected != 44000 {
+		t.Errorf("Expected projected spend 44000, got %d", eatingOut.Projected)
+	}
+
+	groceries := statuses[1]
+	if groceries.Spent != 5000 || groceries.Over {
+		t.Errorf("Unexpected groceries status: %+v", groceries)
+	}
+}
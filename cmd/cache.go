@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cacheDir is the directory under ~/.go-monzo holding cached API data
+const cacheDir = "cache"
+
+// transactionCacheFile returns the cache file name for an account's transactions
+func transactionCacheFile(accountID string) (string, error) {
+	if accountID == "" || strings.ContainsAny(accountID, `/\`) || accountID == "." || accountID == ".." {
+		return "", fmt.Errorf("invalid account ID %q", accountID)
+	}
+	return filepath.Join(cacheDir, "transactions-"+accountID+".json"), nil
+}
+
+// loadTransactionCache loads the cached transactions for an account.
+// An empty response is returned if nothing has been cached yet.
+func loadTransactionCache(accountID string) (*TransactionsResponse, error) {
+	name, err := transactionCacheFile(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := TransactionsResponse{Transactions: []Transaction{}}
+	if err := loadStateFile(name, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to load transaction cache: %w", err)
+	}
+
+	return &transactions, nil
+}
+
+// updateTransactionCache merges transactions into the account's cache. Newer
+// copies of a transaction replace older ones, so settled amounts and notes stay
+// current while history that has aged out of the API is kept.
+func updateTransactionCache(accountID string, transactions []Transaction) error {
+	name, err := transactionCacheFile(accountID)
+	if err != nil {
+		return err
+	}
+
+	cached, err := loadTransactionCache(accountID)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]Transaction, len(cached.Transactions)+len(transactions))
+	for _, tx := range cached.Transactions {
+		byID[tx.ID] = tx
+	}
+	for _, tx := range transactions {
+		byID[tx.ID] = tx
+	}
+
+	merged := make([]Transaction, 0, len(byID))
+	for _, tx := range byID {
+		merged = append(merged, tx)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].CreatedTime().Equal(merged[j].CreatedTime()) {
+			return merged[i].CreatedTime().Before(merged[j].CreatedTime())
+		}
+		return merged[i].ID < merged[j].ID
+	})
+
+	return saveStateFile(name, TransactionsResponse{Transactions: merged})
+}
+
+// transactionCacheSince returns the time to refresh the cache from: the newest
+// cached transaction, or the oldest pending one so that it's fetched again once
+// it settles. The zero time is returned for an empty cache.
+func transactionCacheSince(transactions []Transaction) time.Time {
+	var since time.Time
+	for _, tx := range transactions {
+		if created := tx.CreatedTime(); created.After(since) {
+			since = created
+		}
+	}
+	for _, tx := range transactions {
+		if created := tx.CreatedTime(); tx.IsPending() && created.Before(since) {
+			since = created
+		}
+	}
+	return since
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"time"
+)
+
+// monzoClient is the set of Monzo API calls used by interactive commands, so
+// they can be exercised against a fake in tests
+type monzoClient interface {
+	Accounts(ctx context.Context) ([]Account, error)
+	Balance(ctx context.Context, accountID string) (*BalanceResponse, error)
+	Pots(ctx context.Context, accountID string) ([]Pot, error)
+	Transactions(ctx context.Context, accountID string, since, before time.Time) ([]Transaction, error)
+	Annotate(ctx context.Context, transactionID string, metadata map[string]string) (*Transaction, error)
+	Deposit(ctx context.Context, potID, sourceAccountID string, amount int64, dedupeID string) (*Pot, error)
+}
+
+// apiClient implements monzoClient against the Monzo API
+type apiClient struct {
+	accessToken string
+}
+
+func (c *apiClient) Accounts(ctx context.Context) ([]Account, error) {
+	accounts, err := fetchAccounts(ctx, c.accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return accounts.Accounts, nil
+}
+
+func (c *apiClient) Balance(ctx context.Context, accountID string) (*BalanceResponse, error) {
+	return fetchBalance(ctx, c.accessToken, accountID)
+}
+
+func (c *apiClient) Pots(ctx context.Context, accountID string) ([]Pot, error) {
+	pots, err := fetchPots(ctx, c.accessToken, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return pots.Pots, nil
+}
+
+func (c *apiClient) Transactions(ctx context.Context, accountID string, since, before time.Time) ([]Transaction, error) {
+	transactions, err := fetchTransactions(ctx, c.accessToken, accountID, since, before)
+	if err != nil {
+		return nil, err
+	}
+	return transactions.Transactions, nil
+}
+
+func (c *apiClient) Annotate(ctx context.Context, transactionID string, metadata map[string]string) (*Transaction, error) {
+	return annotateTransaction(ctx, c.accessToken, transactionID, metadata)
+}
+
+func (c *apiClient) Deposit(ctx context.Context, potID, sourceAccountID string, amount int64, dedupeID string) (*Pot, error) {
+	return depositIntoPot(ctx, c.accessToken, potID, sourceAccountID, amount, dedupeID)
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	accountsCacheFile = "cache/accounts.json"
+
+	// accountsCacheTTL is how long fetched accounts are reused for completions
+	accountsCacheTTL = 5 * time.Minute
+
+	// completionTransactionLimit is how many transaction IDs are suggested
+	completionTransactionLimit = 50
+)
+
+// cachedAccounts represents the accounts cached for shell completion
+type cachedAccounts struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Accounts  []Account `json:"accounts"`
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script for go-monzo.
+
+Account IDs are completed from your Monzo accounts, so you need to be logged
+in for them to be suggested. Transaction IDs are completed from the local
+transaction cache, which commands such as 'go-monzo merchants' fill.
+
+Bash:
+  source <(go-monzo completion bash)
+
+  # To load completions for each session, run once:
+  go-monzo completion bash > /etc/bash_completion.d/go-monzo
+
+Zsh:
+  go-monzo completion zsh > "${fpath[1]}/_go-monzo"
+
+Fish:
+  go-monzo completion fish > ~/.config/fish/completions/go-monzo.fish
+
+PowerShell:
+  go-monzo completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	}
+}
+
+// completeAccountIDs suggests the IDs of open accounts, described by type
+func completeAccountIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	accounts, err := completionAccounts(cmd.Context())
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("failed to load accounts: %v", err), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var suggestions []string
+	for _, account := range accounts {
+		if account.Closed || !strings.HasPrefix(account.ID, toComplete) {
+			continue
+		}
+		suggestions = append(suggestions, fmt.Sprintf("%s\t%s %s", account.ID, account.Type, account.Name()))
+	}
+
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionAccounts returns the user's accounts, from a short-lived cache if
+// possible so that completion stays responsive
+func completionAccounts(ctx context.Context) ([]Account, error) {
+	var cached cachedAccounts
+	if err := loadStateFile(accountsCacheFile, &cached); err == nil && time.Since(cached.FetchedAt) < accountsCacheTTL {
+		return cached.Accounts, nil
+	}
+
+	// Completion requests skip the root pre-run hook, so resolve the API here
+	if err := configureEndpoints(); err != nil {
+		return nil, err
+	}
+
+	token, err := loadToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := fetchAccounts(ctx, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = cachedAccounts{FetchedAt: time.Now(), Accounts: accounts.Accounts}
+	if err := saveStateFile(accountsCacheFile, cached); err != nil {
+		cobra.CompDebugln(fmt.Sprintf("failed to cache accounts: %v", err), true)
+	}
+
+	return accounts.Accounts, nil
+}
+
+// completeTransactionIDs suggests the newest transactions from the local
+// transaction caches of the open accounts, described by date, merchant and
+// amount
+func completeTransactionIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	accounts, err := completionAccounts(cmd.Context())
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("failed to load accounts: %v", err), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var transactions []Transaction
+	for _, account := range accounts {
+		if account.Closed {
+			continue
+		}
+		cached, err := loadTransactionCache(account.ID)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("failed to load transactions for %s: %v", account.ID, err), true)
+			continue
+		}
+		for _, tx := range cached.Transactions {
+			if !tx.IsDeclined() && strings.HasPrefix(tx.ID, toComplete) {
+				transactions = append(transactions, tx)
+			}
+		}
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].CreatedTime().After(transactions[j].CreatedTime())
+	})
+	if len(transactions) > completionTransactionLimit {
+		transactions = transactions[:completionTransactionLimit]
+	}
+
+	suggestions := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		suggestions = append(suggestions, fmt.Sprintf("%s\t%s %s %s", tx.ID, tx.CreatedTime().Local().Format(dateLayout), tx.MerchantName(), NewMoney(tx.Amount, tx.Currency).String()))
+	}
+
+	// Keep the newest first rather than letting the shell sort them
+	return suggestions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// fixedCompletions returns a completion function suggesting the given values
+func fixedCompletions(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeCurrencies suggests the known ISO 4217 currency codes
+func completeCurrencies(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteAccountIDsFromCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cached := cachedAccounts{
+		FetchedAt: time.Now(),
+		Accounts: []Account{
+			{ID: "acc_123", Description: "Personal", Type: "uk_retail"},
+			{ID: "acc_456", Description: "Joint", Type: "uk_retail_joint"},
+			{ID: "acc_789", Description: "Old", Type: "uk_retail", Closed: true},
+			{ID: "acc_999", Description: "Other", Type: "uk_retail"},
+		},
+	}
+	if err := saveStateFile(accountsCacheFile, cached); err != nil {
+		t.Fatalf("Failed to save accounts cache: %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	suggestions, _ := completeAccountIDs(cmd, nil, "acc_")
+	if len(suggestions) != 3 {
+		t.Fatalf("Expected 3 open accounts, got %v", suggestions)
+	}
+	if suggestions[0] != "acc_123\tuk_retail Personal" {
+		t.Errorf("Unexpected suggestion: %q", suggestions[0])
+	}
+
+	suggestions, _ = completeAccountIDs(cmd, nil, "acc_4")
+	if len(suggestions) != 1 || suggestions[0] != "acc_456\tuk_retail_joint Joint" {
+		t.Errorf("Expected only acc_456, got %v", suggestions)
+	}
+}
+
+func TestCompleteTransactionIDsFromCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cached := cachedAccounts{
+		FetchedAt: time.Now(),
+		Accounts: []Account{
+			{ID: "acc_123", Description: "Personal", Type: "uk_retail"},
+			{ID: "acc_456", Description: "Joint", Type: "uk_retail_joint"},
+		},
+	}
+	if err := saveStateFile(accountsCacheFile, cached); err != nil {
+		t.Fatalf("Failed to save accounts cache: %v", err)
+	}
+	if err := updateTransactionCache("acc_123", []Transaction{
+		{ID: "tx_old", Created: "2024-03-01T12:00:00Z", Amount: -450, Currency: "GBP", Description: "PRET"},
+		{ID: "tx_declined", Created: "2024-03-04T12:00:00Z", Amount: -9900, Currency: "GBP", DeclineReason: "INSUFFICIENT_FUNDS"},
+	}); err != nil {
+		t.Fatalf("Failed to save transaction cache: %v", err)
+	}
+	if err := updateTransactionCache("acc_456", []Transaction{
+		{ID: "tx_new", Created: "2024-03-05T12:00:00Z", Amount: -150000, Currency: "GBP", Description: "RENT"},
+	}); err != nil {
+		t.Fatalf("Failed to save transaction cache: %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	suggestions, _ := completeTransactionIDs(cmd, nil, "tx_")
+	if len(suggestions) != 2 || !strings.HasPrefix(suggestions[0], "tx_new\t") || !strings.HasPrefix(suggestions[1], "tx_old\t") {
+		t.Fatalf("Expected the newest transactions first without declines, got %q", suggestions)
+	}
+	if !strings.Contains(suggestions[0], "RENT -£1,500.00") {
+		t.Errorf("Expected the merchant and amount in the description, got %q", suggestions[0])
+	}
+
+	suggestions, _ = completeSplitAdd(cmd, nil, "tx_o")
+	if len(suggestions) != 1 || !strings.HasPrefix(suggestions[0], "tx_old\t") {
+		t.Errorf("Expected split add to complete transaction IDs, got %q", suggestions)
+	}
+}
@@ -2,14 +2,35 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Config represents the configuration stored in the config file
 type Config struct {
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
+	APIBaseURL   string `json:"api_base_url,omitempty"`
+	AuthURL      string `json:"auth_url,omitempty"`
+
+	// Assets and liabilities held outside Monzo, counted by 'go-monzo networth'
+	Assets      []ManualHolding `json:"assets,omitempty"`
+	Liabilities []ManualHolding `json:"liabilities,omitempty"`
+
+	// SalaryPayer picks which recurring income marks payday, matched against
+	// the payer's name. The largest recurring income is used if it's unset.
+	SalaryPayer string `json:"salary_payer,omitempty"`
+}
+
+// ManualHolding represents an asset or liability held outside Monzo, such as
+// an ISA or a mortgage
+type ManualHolding struct {
+	Name     string `json:"name"`
+	Amount   int64  `json:"amount"` // Amount in minor units, always positive
+	Currency string `json:"currency"`
 }
 
 // LoadConfig loads the configuration from the config file at ~/.go-monzo/config.json
@@ -70,3 +91,115 @@ func GetClientCredentials(flagClientID, flagClientSecret string) (string, string
 
 	return clientID, clientSecret
 }
+
+// GetEndpoints returns the API base URL and auth URL with the same priority as
+// GetClientCredentials, falling back to the Monzo production URLs
+func GetEndpoints(flagAPIBaseURL, flagAuthURL string) (string, string) {
+	baseURL := flagAPIBaseURL
+	authURL := flagAuthURL
+
+	if baseURL == "" {
+		baseURL = os.Getenv("MONZO_API_BASE_URL")
+	}
+	if authURL == "" {
+		authURL = os.Getenv("MONZO_AUTH_URL")
+	}
+
+	if baseURL == "" || authURL == "" {
+		config, err := LoadConfig()
+		if err == nil && config != nil {
+			if baseURL == "" {
+				baseURL = config.APIBaseURL
+			}
+			if authURL == "" {
+				authURL = config.AuthURL
+			}
+		}
+	}
+
+	if baseURL == "" {
+		baseURL = monzoAPIBaseURL
+	}
+	if authURL == "" {
+		authURL = monzoAuthURL
+	}
+
+	return baseURL, authURL
+}
+
+// validateEndpoint checks that value is an absolute http or https URL and
+// returns it without a trailing slash, so paths can be appended directly
+func validateEndpoint(name, value string) (string, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid %s %q: must be an http or https URL", name, value)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: missing host", name, value)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid %s %q: must not have a query or fragment", name, value)
+	}
+	return strings.TrimRight(value, "/"), nil
+}
+
+// configureEndpoints resolves and validates the API and auth URLs from the
+// global flags, environment and config file
+func configureEndpoints() error {
+	baseURL, auth := GetEndpoints(flagAPIBaseURL, flagAuthURL)
+
+	baseURL, err := validateEndpoint("API base URL", baseURL)
+	if err != nil {
+		return err
+	}
+	auth, err = validateEndpoint("auth URL", auth)
+	if err != nil {
+		return err
+	}
+
+	apiBaseURL = baseURL
+	authURL = auth
+	return nil
+}
+
+// loadStateFile reads a JSON file from the config directory into v.
+// v is left unchanged if the file doesn't exist.
+func loadStateFile(name string, v interface{}) error {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// saveStateFile writes v as JSON to a file in the config directory
+func saveStateFile(name string, v interface{}) error {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(configDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
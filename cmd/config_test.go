@@ -350,3 +350,78 @@ func TestGetClientCredentialsPartialFromConfig(t *testing.T) {
 		t.Errorf("Expected clientSecret 'config_client_secret', got '%s'", clientSecret)
 	}
 }
+
+func TestGetEndpointsPriority(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("MONZO_API_BASE_URL", "")
+	t.Setenv("MONZO_AUTH_URL", "")
+
+	// Defaults when nothing is configured
+	baseURL, auth := GetEndpoints("", "")
+	if baseURL != monzoAPIBaseURL || auth != monzoAuthURL {
+		t.Errorf("Expected defaults, got %q and %q", baseURL, auth)
+	}
+
+	if err := saveStateFile("config.json", Config{APIBaseURL: "http://config.test", AuthURL: "http://config-auth.test"}); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	baseURL, auth = GetEndpoints("", "")
+	if baseURL != "http://config.test" || auth != "http://config-auth.test" {
+		t.Errorf("Expected config file URLs, got %q and %q", baseURL, auth)
+	}
+
+	t.Setenv("MONZO_API_BASE_URL", "http://env.test")
+	baseURL, auth = GetEndpoints("", "")
+	if baseURL != "http://env.test" || auth != "http://config-auth.test" {
+		t.Errorf("Expected env API URL and config auth URL, got %q and %q", baseURL, auth)
+	}
+
+	baseURL, auth = GetEndpoints("http://flag.test", "http://flag-auth.test")
+	if baseURL != "http://flag.test" || auth != "http://flag-auth.test" {
+		t.Errorf("Expected flag URLs, got %q and %q", baseURL, auth)
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"https://api.monzo.com", "https://api.monzo.com", false},
+		{"http://127.0.0.1:8000/", "http://127.0.0.1:8000", false},
+		{"https://proxy.test/monzo/", "https://proxy.test/monzo", false},
+		{"api.monzo.com", "", true},
+		{"ftp://api.monzo.com", "", true},
+		{"https://", "", true},
+		{"https://api.monzo.com?x=1", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := validateEndpoint("API base URL", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateEndpoint(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("validateEndpoint(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConfigureEndpointsRejectsInvalidURL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MONZO_API_BASE_URL", "not a url")
+	t.Setenv("MONZO_AUTH_URL", "")
+
+	originalBaseURL := apiBaseURL
+	t.Cleanup(func() { apiBaseURL = originalBaseURL })
+
+	if err := configureEndpoints(); err == nil {
+		t.Fatal("Expected an error for an invalid API base URL")
+	}
+	if apiBaseURL != originalBaseURL {
+		t.Errorf("Expected API base URL to be unchanged, got %q", apiBaseURL)
+	}
+}
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redactedValue = "[REDACTED]"
+
+var (
+	debugEnabled bool
+	debugHARFile string
+
+	// harEntries collects the traffic recorded for --debug-har. It is written
+	// out once the command has finished.
+	harEntries   []harEntry
+	harEntriesMu sync.Mutex
+)
+
+// sensitiveFields lists request and response body fields that must never be
+// logged or written to a HAR file.
+var sensitiveFields = map[string]bool{
+	"client_secret": true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+// debugFromEnv reports whether MONZO_DEBUG is set to a true value
+func debugFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("MONZO_DEBUG"))
+	return err == nil && enabled
+}
+
+// newHTTPClient returns the HTTP client used for every call to the Monzo API.
+// When debugging is enabled the client traces requests and responses.
+func newHTTPClient() *http.Client {
+	if !debugEnabled && debugHARFile == "" {
+		return &http.Client{}
+	}
+
+	var logOutput io.Writer
+	if debugEnabled {
+		logOutput = os.Stderr
+	}
+
+	return &http.Client{
+		Transport: &debugTransport{
+			next:      http.DefaultTransport,
+			logOutput: logOutput,
+			recordHAR: debugHARFile != "",
+		},
+	}
+}
+
+// debugTransport is an http.RoundTripper that logs and records traffic with
+// secrets redacted
+type debugTransport struct {
+	next      http.RoundTripper
+	logOutput io.Writer
+	recordHAR bool
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	started := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(started)
+
+	redactedURL := redactURL(req.URL)
+	redactedReqBody := redactBody(req.Header.Get("Content-Type"), reqBody)
+
+	if err != nil {
+		if t.logOutput != nil {
+			fmt.Fprintf(t.logOutput, "--> %s %s\n", req.Method, redactedURL)
+			writeDebugHeaders(t.logOutput, req.Header)
+			writeDebugBody(t.logOutput, redactedReqBody)
+			fmt.Fprintf(t.logOutput, "<-- %s %s failed after %s: %v\n", req.Method, redactedURL, elapsed.Round(time.Millisecond), err)
+		}
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	redactedRespBody := redactBody(resp.Header.Get("Content-Type"), respBody)
+
+	if t.logOutput != nil {
+		fmt.Fprintf(t.logOutput, "--> %s %s\n", req.Method, redactedURL)
+		writeDebugHeaders(t.logOutput, req.Header)
+		writeDebugBody(t.logOutput, redactedReqBody)
+		fmt.Fprintf(t.logOutput, "<-- %s %s %s (%s)\n", resp.Status, req.Method, redactedURL, elapsed.Round(time.Millisecond))
+		writeDebugBody(t.logOutput, redactedRespBody)
+	}
+
+	if t.recordHAR {
+		recordHAREntry(req, resp, redactedURL, redactedReqBody, redactedRespBody, started, elapsed)
+	}
+
+	return resp, nil
+}
+
+func writeDebugHeaders(w io.Writer, header http.Header) {
+	for name, values := range redactHeaders(header) {
+		for _, value := range values {
+			fmt.Fprintf(w, "    %s: %s\n", name, value)
+		}
+	}
+}
+
+func writeDebugBody(w io.Writer, body string) {
+	if body == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// redactHeaders returns a copy of the headers with credentials removed
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted.Get("Authorization") != "" {
+		scheme, _, _ := strings.Cut(redacted.Get("Authorization"), " ")
+		redacted.Set("Authorization", scheme+" "+redactedValue)
+	}
+	return redacted
+}
+
+// redactURL returns the URL as a string with sensitive query parameters removed
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	redacted := *u
+	query := redacted.Query()
+	redactValues(query)
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// redactBody returns the body as a string with sensitive fields removed.
+// Form and JSON bodies are understood; anything else is returned unchanged.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		redactValues(values)
+		return values.Encode()
+	case strings.Contains(contentType, "json") || json.Valid(body):
+		var decoded interface{}
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			return string(body)
+		}
+		output, err := json.MarshalIndent(redactJSON(decoded), "", "  ")
+		if err != nil {
+			return string(body)
+		}
+		return string(output)
+	default:
+		return string(body)
+	}
+}
+
+func redactValues(values url.Values) {
+	for key := range values {
+		if sensitiveFields[key] {
+			values.Set(key, redactedValue)
+		}
+	}
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[key] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// HAR 1.2 structures, limited to the fields go-monzo records
+type harLog struct {
+	Log harLogBody `json:"log"`
+}
+
+type harLogBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func recordHAREntry(req *http.Request, resp *http.Response, redactedURL, reqBody, respBody string, started time.Time, elapsed time.Duration) {
+	millis := float64(elapsed) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            millis,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         redactedURL,
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(redactHeaders(req.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(redactHeaders(resp.Header)),
+			Content: harContent{
+				Size:     len(respBody),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     respBody,
+			},
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Timings: harTimings{Send: 0, Wait: millis, Receive: 0},
+	}
+
+	if parsed, err := url.Parse(redactedURL); err == nil {
+		for key, values := range parsed.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: value})
+			}
+		}
+	}
+
+	if reqBody != "" {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     reqBody,
+		}
+	}
+
+	harEntriesMu.Lock()
+	harEntries = append(harEntries, entry)
+	harEntriesMu.Unlock()
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// writeDebugHAR writes the recorded traffic to the --debug-har file, if set
+func writeDebugHAR() error {
+	if debugHARFile == "" {
+		return nil
+	}
+
+	harEntriesMu.Lock()
+	entries := harEntries
+	harEntriesMu.Unlock()
+
+	if entries == nil {
+		entries = []harEntry{}
+	}
+
+	har := harLog{
+		Log: harLogBody{
+			Version: "1.2",
+			Creator: harCreator{Name: "go-monzo", Version: "dev"},
+			Entries: entries,
+		},
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR file: %w", err)
+	}
+
+	if err := os.WriteFile(debugHARFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+
+	return nil
+}
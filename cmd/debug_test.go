@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactBodyForm(t *testing.T) {
+	body := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {"client_123"},
+		"client_secret": {"super_secret"},
+		"refresh_token": {"refresh_abc"},
+	}.Encode()
+
+	redacted := redactBody("application/x-www-form-urlencoded", []byte(body))
+
+	if strings.Contains(redacted, "super_secret") {
+		t.Error("Expected client_secret to be redacted")
+	}
+
+	if strings.Contains(redacted, "refresh_abc") {
+		t.Error("Expected refresh_token to be redacted")
+	}
+
+	if !strings.Contains(redacted, "client_123") {
+		t.Error("Expected client_id to be kept")
+	}
+}
+
+func TestRedactBodyJSON(t *testing.T) {
+	body := `{"access_token":"access_abc","refresh_token":"refresh_abc","user_id":"user_123","nested":[{"access_token":"inner"}]}`
+
+	redacted := redactBody("application/json", []byte(body))
+
+	for _, secret := range []string{"access_abc", "refresh_abc", "inner"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("Expected %q to be redacted, got: %s", secret, redacted)
+		}
+	}
+
+	if !strings.Contains(redacted, "user_123") {
+		t.Error("Expected user_id to be kept")
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer access_abc")
+	header.Set("Content-Type", "application/json")
+
+	redacted := redactHeaders(header)
+
+	if got := redacted.Get("Authorization"); got != "Bearer "+redactedValue {
+		t.Errorf("Expected redacted Authorization header, got '%s'", got)
+	}
+
+	if header.Get("Authorization") != "Bearer access_abc" {
+		t.Error("Expected original headers to be left untouched")
+	}
+}
+
+func TestDebugTransportLogsWithoutSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new_access_token","user_id":"user_123"}`))
+	}))
+	defer server.Close()
+
+	var logOutput bytes.Buffer
+	client := &http.Client{
+		Transport: &debugTransport{next: http.DefaultTransport, logOutput: &logOutput},
+	}
+
+	req, err := http.NewRequest("POST", server.URL+"/oauth2/token", strings.NewReader("client_secret=super_secret"))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer old_access_token")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+
+	if !strings.Contains(body.String(), "new_access_token") {
+		t.Error("Expected the caller to receive the unredacted response body")
+	}
+
+	logged := logOutput.String()
+	for _, secret := range []string{"super_secret", "old_access_token", "new_access_token"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted from debug log:\n%s", secret, logged)
+		}
+	}
+
+	if !strings.Contains(logged, "200 OK POST") {
+		t.Errorf("Expected status line in debug log:\n%s", logged)
+	}
+}
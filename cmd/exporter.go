@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterListen   string
+	exporterInterval time.Duration
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Expose account metrics for Prometheus",
+	Long: `Serve Prometheus metrics for every open account on /metrics.
+
+Balances, total balances, today's spending and pot balances are exposed as
+gauges in major currency units. Counters of transactions and spending by
+category grow as new transactions appear, starting from the transactions
+available when the exporter starts. Categories reflect the local rules in
+~/.go-monzo/rules.json.
+
+Data is refreshed from the Monzo API every --interval and cached in between,
+so scrapes never call the API directly.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runExporter,
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", ":9090", "Address to serve metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", 5*time.Minute, "Time between refreshes from the Monzo API")
+}
+
+func runExporter(cmd *cobra.Command, args []string) error {
+	if exporterInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+
+	// Load the stored token
+	if _, err := loadToken(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	exporter := newMetricsExporter(func(ctx context.Context) (monzoClient, error) {
+		// Load the token on every refresh so it is refreshed when it expires
+		token, err := loadToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load token: %w", err)
+		}
+		return &apiClient{accessToken: token.AccessToken}, nil
+	})
+
+	ctx := cmd.Context()
+	if err := exporter.refresh(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(exporterInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := exporter.refresh(ctx); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+	})
+
+	fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics\n", exporterListen)
+	server := &http.Server{Addr: exporterListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return serveUntilDone(ctx, server, server.ListenAndServe)
+}
+
+// categoryKey identifies a transaction counter
+type categoryKey struct {
+	AccountID string
+	Category  string
+	Currency  string
+}
+
+// metricsExporter caches account data from the Monzo API and renders it in
+// the Prometheus text format
+type metricsExporter struct {
+	newClient func(ctx context.Context) (monzoClient, error)
+
+	mu            sync.Mutex
+	accounts      []Account
+	balances      map[string]*BalanceResponse
+	pots          map[string][]Pot
+	since         map[string]time.Time       // Newest transaction counted per account
+	seen          map[string]map[string]bool // Transactions counted at since, per account
+	transactions  map[categoryKey]int64
+	spend         map[categoryKey]int64 // Minor units
+	lastRefresh   time.Time
+	refreshErrors int64
+}
+
+func newMetricsExporter(newClient func(ctx context.Context) (monzoClient, error)) *metricsExporter {
+	return &metricsExporter{
+		newClient:    newClient,
+		balances:     make(map[string]*BalanceResponse),
+		pots:         make(map[string][]Pot),
+		since:        make(map[string]time.Time),
+		seen:         make(map[string]map[string]bool),
+		transactions: make(map[categoryKey]int64),
+		spend:        make(map[categoryKey]int64),
+	}
+}
+
+// refresh fetches the latest data for every open account
+func (e *metricsExporter) refresh(ctx context.Context) error {
+	err := e.fetch(ctx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		e.refreshErrors++
+		return fmt.Errorf("failed to refresh metrics: %w", err)
+	}
+	e.lastRefresh = time.Now()
+	return nil
+}
+
+func (e *metricsExporter) fetch(ctx context.Context) error {
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := client.Accounts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	var open []Account
+	for _, account := range accounts {
+		if !account.Closed {
+			open = append(open, account)
+		}
+	}
+
+	balances := make(map[string]*BalanceResponse)
+	pots := make(map[string][]Pot)
+	newTransactions := make(map[string][]Transaction)
+
+	for _, account := range open {
+		balance, err := client.Balance(ctx, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch balance for %s: %w", account.ID, err)
+		}
+		balances[account.ID] = balance
+
+		accountPots, err := client.Pots(ctx, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch pots for %s: %w", account.ID, err)
+		}
+		pots[account.ID] = openPots(accountPots)
+
+		e.mu.Lock()
+		since := e.since[account.ID]
+		e.mu.Unlock()
+
+		transactions, err := client.Transactions(ctx, account.ID, since, time.Time{})
+		if err != nil {
+			return fmt.Errorf("failed to fetch transactions for %s: %w", account.ID, err)
+		}
+		if err := categorise(transactions); err != nil {
+			return err
+		}
+		newTransactions[account.ID] = transactions
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.accounts = open
+	e.balances = balances
+	e.pots = pots
+
+	for accountID, transactions := range newTransactions {
+		since, seen := e.since[accountID], e.seen[accountID]
+		newest := since
+		for _, tx := range transactions {
+			// Transactions before since were counted by an earlier refresh, as
+			// were those at since that are in seen
+			created := tx.CreatedTime()
+			if created.Before(since) || created.Equal(since) && seen[tx.ID] {
+				continue
+			}
+			if created.After(newest) {
+				newest = created
+			}
+			if tx.IsDeclined() {
+				continue
+			}
+
+			key := categoryKey{AccountID: accountID, Category: tx.Category, Currency: tx.Currency}
+			e.transactions[key]++
+			if tx.IsSpending() {
+				e.spend[key] += -tx.Amount
+			}
+		}
+
+		// The next fetch starts at the newest transaction, so only the
+		// transactions created then need remembering
+		next := make(map[string]bool)
+		for _, tx := range transactions {
+			if tx.CreatedTime().Equal(newest) {
+				next[tx.ID] = true
+			}
+		}
+		e.since[accountID] = newest
+		e.seen[accountID] = next
+	}
+
+	return nil
+}
+
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.writeMetrics(w)
+}
+
+// writeMetrics renders the cached data in the Prometheus text format
+func (e *metricsExporter) writeMetrics(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var balance, totalBalance, spendToday, potBalance []metricSample
+	for _, account := range e.accounts {
+		b, ok := e.balances[account.ID]
+		if !ok {
+			continue
+		}
+		labels := []string{"account_id", account.ID, "account_type", account.Type, "currency", b.Currency}
+		balance = append(balance, metricSample{labels, NewMoney(b.Balance, b.Currency).Major()})
+		totalBalance = append(totalBalance, metricSample{labels, NewMoney(b.TotalBalance, b.Currency).Major()})
+		spendToday = append(spendToday, metricSample{labels, NewMoney(-b.SpendToday, b.Currency).Major()})
+
+		for _, pot := range e.pots[account.ID] {
+			potBalance = append(potBalance, metricSample{
+				[]string{"account_id", account.ID, "pot_id", pot.ID, "pot_name", pot.Name, "currency", pot.Currency},
+				NewMoney(pot.Balance, pot.Currency).Major(),
+			})
+		}
+	}
+
+	var transactions, spend []metricSample
+	for key, count := range e.transactions {
+		labels := []string{"account_id", key.AccountID, "category", key.Category, "currency", key.Currency}
+		transactions = append(transactions, metricSample{labels, float64(count)})
+		spend = append(spend, metricSample{labels, NewMoney(e.spend[key], key.Currency).Major()})
+	}
+
+	writeMetric(w, "monzo_balance", "gauge", "Balance of the account in major currency units.", balance)
+	writeMetric(w, "monzo_total_balance", "gauge", "Balance of the account including pots in major currency units.", totalBalance)
+	writeMetric(w, "monzo_spend_today", "gauge", "Amount spent today in major currency units.", spendToday)
+	writeMetric(w, "monzo_pot_balance", "gauge", "Balance of the pot in major currency units.", potBalance)
+	writeMetric(w, "monzo_transactions_total", "counter", "Transactions seen, by category.", transactions)
+	writeMetric(w, "monzo_spend_total", "counter", "Spending seen in major currency units, by category.", spend)
+
+	var lastRefresh []metricSample
+	if !e.lastRefresh.IsZero() {
+		lastRefresh = append(lastRefresh, metricSample{nil, float64(e.lastRefresh.Unix())})
+	}
+	writeMetric(w, "monzo_exporter_last_refresh_timestamp_seconds", "gauge", "Time of the last successful refresh from the Monzo API.", lastRefresh)
+	writeMetric(w, "monzo_exporter_refresh_errors_total", "counter", "Failed refreshes from the Monzo API.", []metricSample{{nil, float64(e.refreshErrors)}})
+}
+
+// metricSample is a single value of a metric. Labels are name, value pairs.
+type metricSample struct {
+	Labels []string
+	Value  float64
+}
+
+// writeMetric writes a metric family in the Prometheus text format
+func writeMetric(w io.Writer, name, metricType, help string, samples []metricSample) {
+	lines := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		var labels []string
+		for i := 0; i+1 < len(sample.Labels); i += 2 {
+			labels = append(labels, fmt.Sprintf("%s=\"%s\"", sample.Labels[i], escapeLabelValue(sample.Labels[i+1])))
+		}
+
+		line := name
+		if len(labels) > 0 {
+			line += "{" + strings.Join(labels, ",") + "}"
+		}
+		lines = append(lines, line+" "+strconv.FormatFloat(sample.Value, 'f', -1, 64))
+	}
+	sort.Strings(lines)
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// escapeLabelValue escapes a label value for the Prometheus text format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	client := newFakeClient()
+	client.pots["acc_1"][0].Name = `Holiday "fund"`
+
+	exporter := newMetricsExporter(func(context.Context) (monzoClient, error) { return client, nil })
+
+	// Refreshing twice must not count transactions twice
+	for i := 0; i < 2; i++ {
+		if err := exporter.refresh(context.Background()); err != nil {
+			t.Fatalf("Failed to refresh: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	metrics := string(body)
+
+	for _, want := range []string{
+		"# TYPE monzo_balance gauge",
+		`monzo_balance{account_id="acc_1",account_type="uk_retail",currency="GBP"} 1234.56`,
+		`monzo_spend_today{account_id="acc_2",account_type="uk_retail_joint",currency="GBP"} 4.5`,
+		`monzo_pot_balance{account_id="acc_1",pot_id="pot_1",pot_name="Holiday \"fund\"",currency="GBP"} 100`,
+		"# TYPE monzo_transactions_total counter",
+		`monzo_transactions_total{account_id="acc_1",category="groceries",currency="GBP"} 1`,
+		`monzo_transactions_total{account_id="acc_2",category="bills",currency="GBP"} 1`,
+		"monzo_exporter_refresh_errors_total 0",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, metrics)
+		}
+	}
+
+	if strings.Contains(metrics, "acc_old") {
+		t.Errorf("Closed accounts should not be exported")
+	}
+	if strings.Contains(metrics, "Old pot") {
+		t.Errorf("Deleted pots should not be exported")
+	}
+}
+
+func TestMetricsExporterRemembersOnlyTheNewestTransactions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	client := newFakeClient()
+	exporter := newMetricsExporter(func(context.Context) (monzoClient, error) { return client, nil })
+	if err := exporter.refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// A transaction made at the same time as the newest is still counted
+	newest := client.transactions["acc_1"][len(client.transactions["acc_1"])-1]
+	client.transactions["acc_1"] = append(client.transactions["acc_1"],
+		Transaction{ID: "tx_same_time", Created: newest.Created, Amount: -100, Currency: "GBP", Category: newest.Category})
+	before := exporter.transactions[categoryKey{AccountID: "acc_1", Category: newest.Category, Currency: "GBP"}]
+	if err := exporter.refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	if got := exporter.transactions[categoryKey{AccountID: "acc_1", Category: newest.Category, Currency: "GBP"}]; got != before+1 {
+		t.Errorf("Expected the new transaction to be counted once, got %d after %d", got, before)
+	}
+	if seen := exporter.seen["acc_1"]; len(seen) != 2 || !seen[newest.ID] || !seen["tx_same_time"] {
+		t.Errorf("Expected only the newest transactions to be remembered, got %v", seen)
+	}
+}
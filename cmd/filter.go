@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A filter expression selects transactions, for example:
+//
+//	amount < -5000 && category == "groceries" && merchant ~ "Tesco"
+//
+// Comparisons take a field on the left and a literal on the right. Supported
+// operators are == != < <= > >= and ~ / !~ for case-insensitive regular
+// expression matches. Comparisons can be combined with &&, || and !, and
+// grouped with parentheses. Boolean fields can be used on their own.
+
+// filterKind is the type of a filter field or literal
+type filterKind int
+
+const (
+	kindString filterKind = iota
+	kindNumber
+	kindBool
+)
+
+func (k filterKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// filterValue is a field value or literal in a filter expression
+type filterValue struct {
+	kind    filterKind
+	str     string
+	num     int64
+	boolean bool
+}
+
+// filterField describes a transaction field available to filter expressions
+type filterField struct {
+	kind filterKind
+	get  func(tx Transaction) filterValue
+}
+
+func stringField(get func(tx Transaction) string) filterField {
+	return filterField{kind: kindString, get: func(tx Transaction) filterValue {
+		return filterValue{kind: kindString, str: get(tx)}
+	}}
+}
+
+func numberField(get func(tx Transaction) int64) filterField {
+	return filterField{kind: kindNumber, get: func(tx Transaction) filterValue {
+		return filterValue{kind: kindNumber, num: get(tx)}
+	}}
+}
+
+func boolField(get func(tx Transaction) bool) filterField {
+	return filterField{kind: kindBool, get: func(tx Transaction) filterValue {
+		return filterValue{kind: kindBool, boolean: get(tx)}
+	}}
+}
+
+func merchantField(tx Transaction) Merchant {
+	if tx.Merchant == nil {
+		return Merchant{}
+	}
+	return *tx.Merchant
+}
+
+var filterFields = map[string]filterField{
+	"id":                  stringField(func(tx Transaction) string { return tx.ID }),
+	"created":             stringField(func(tx Transaction) string { return tx.Created }),
+	"description":         stringField(func(tx Transaction) string { return tx.Description }),
+	"amount":              numberField(func(tx Transaction) int64 { return tx.Amount }),
+	"currency":            stringField(func(tx Transaction) string { return tx.Currency }),
+	"notes":               stringField(func(tx Transaction) string { return tx.Notes }),
+	"category":            stringField(func(tx Transaction) string { return tx.Category }),
+	"settled":             stringField(func(tx Transaction) string { return tx.Settled }),
+	"account_balance":     numberField(func(tx Transaction) int64 { return tx.AccountBalance }),
+	"local_amount":        numberField(func(tx Transaction) int64 { return tx.LocalAmount }),
+	"local_currency":      stringField(func(tx Transaction) string { return tx.LocalCurrency }),
+	"decline_reason":      stringField(func(tx Transaction) string { return tx.DeclineReason }),
+	"declined":            boolField(Transaction.IsDeclined),
+	"pending":             boolField(Transaction.IsPending),
+	"is_load":             boolField(func(tx Transaction) bool { return tx.IsLoad }),
+	"include_in_spending": boolField(func(tx Transaction) bool { return tx.IncludeInSpending }),
+	"merchant":            stringField(Transaction.MerchantName),
+	"merchant.id":         stringField(func(tx Transaction) string { return merchantField(tx).ID }),
+	"merchant.group_id":   stringField(func(tx Transaction) string { return merchantField(tx).GroupID }),
+	"merchant.name":       stringField(func(tx Transaction) string { return merchantField(tx).Name }),
+	"merchant.category":   stringField(func(tx Transaction) string { return merchantField(tx).Category }),
+	"merchant.online":     boolField(func(tx Transaction) bool { return merchantField(tx).Online }),
+	"merchant.atm":        boolField(func(tx Transaction) bool { return merchantField(tx).ATM }),
+	"tags":                stringField(func(tx Transaction) string { return strings.Join(tx.Tags, ",") }),
+}
+
+// lookupFilterField returns the field for a name. Metadata keys are
+// available as metadata.<key>.
+func lookupFilterField(name string) (filterField, bool) {
+	if key, ok := strings.CutPrefix(name, "metadata."); ok && key != "" {
+		return stringField(func(tx Transaction) string { return tx.Metadata[key] }), true
+	}
+	field, ok := filterFields[name]
+	return field, ok
+}
+
+// transactionFilter reports whether a transaction matches
+type transactionFilter func(tx Transaction) bool
+
+// compileFilter parses a filter expression
+func compileFilter(expr string) (transactionFilter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return filter, nil
+}
+
+// filterTransactions returns the transactions matching every filter
+func filterTransactions(transactions []Transaction, filters ...transactionFilter) []Transaction {
+	matched := []Transaction{}
+	for _, tx := range transactions {
+		keep := true
+		for _, filter := range filters {
+			if !filter(tx) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			matched = append(matched, tx)
+		}
+	}
+	return matched
+}
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("unexpected %q at position %d", string(r), i)
+			}
+			kind := tokAnd
+			if r == '|' {
+				kind = tokOr
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: string([]rune{r, r}), pos: i})
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, filterToken{kind: tokString, text: b.String(), pos: start})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>' || r == '~':
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '!' && runes[i+1] == '~')) {
+				op += string(runes[i+1])
+			}
+			i += len([]rune(op))
+			switch op {
+			case "!":
+				tokens = append(tokens, filterToken{kind: tokNot, text: op, pos: start})
+			case "=":
+				return nil, fmt.Errorf("unexpected \"=\" at position %d, did you mean \"==\"?", start)
+			default:
+				tokens = append(tokens, filterToken{kind: tokOp, text: op, pos: start})
+			}
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokIdent, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", string(r), i)
+		}
+	}
+
+	return append(tokens, filterToken{kind: tokEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) parseOr() (transactionFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tx Transaction) bool { return l(tx) || right(tx) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (transactionFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tx Transaction) bool { return l(tx) && right(tx) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (transactionFilter, error) {
+	switch tok := p.peek(); tok.kind {
+	case tokNot:
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(tx Transaction) bool { return !inner(tx) }, nil
+	case tokLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d, got %q", closing.pos, closing.text)
+		}
+		return inner, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (transactionFilter, error) {
+	ident := p.next()
+	if ident.kind != tokIdent {
+		return nil, fmt.Errorf("expected field name at position %d, got %q", ident.pos, ident.text)
+	}
+
+	field, ok := lookupFilterField(ident.text)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", ident.text, ident.pos)
+	}
+
+	if p.peek().kind != tokOp {
+		if field.kind != kindBool {
+			return nil, fmt.Errorf("field %q is a %s and needs a comparison", ident.text, field.kind)
+		}
+		return func(tx Transaction) bool { return field.get(tx).boolean }, nil
+	}
+
+	op := p.next()
+	literalTok := p.next()
+	literal, err := parseFilterLiteral(literalTok)
+	if err != nil {
+		return nil, err
+	}
+
+	if op.text == "~" || op.text == "!~" {
+		if field.kind != kindString || literal.kind != kindString {
+			return nil, fmt.Errorf("operator %q at position %d needs a string field and pattern", op.text, op.pos)
+		}
+		re, err := regexp.Compile("(?i)" + literal.str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern at position %d: %w", literalTok.pos, err)
+		}
+		negate := op.text == "!~"
+		return func(tx Transaction) bool { return re.MatchString(field.get(tx).str) != negate }, nil
+	}
+
+	if field.kind != literal.kind {
+		return nil, fmt.Errorf("cannot compare %s field %q with %s at position %d", field.kind, ident.text, literal.kind, literalTok.pos)
+	}
+
+	if field.kind == kindBool && op.text != "==" && op.text != "!=" {
+		return nil, fmt.Errorf("operator %q at position %d is not supported for boolean fields", op.text, op.pos)
+	}
+
+	compare := func(tx Transaction) int {
+		value := field.get(tx)
+		switch field.kind {
+		case kindNumber:
+			return compareInt64(value.num, literal.num)
+		case kindBool:
+			if value.boolean == literal.boolean {
+				return 0
+			}
+			return 1
+		default:
+			return strings.Compare(value.str, literal.str)
+		}
+	}
+
+	switch op.text {
+	case "==":
+		return func(tx Transaction) bool { return compare(tx) == 0 }, nil
+	case "!=":
+		return func(tx Transaction) bool { return compare(tx) != 0 }, nil
+	case "<":
+		return func(tx Transaction) bool { return compare(tx) < 0 }, nil
+	case "<=":
+		return func(tx Transaction) bool { return compare(tx) <= 0 }, nil
+	case ">":
+		return func(tx Transaction) bool { return compare(tx) > 0 }, nil
+	case ">=":
+		return func(tx Transaction) bool { return compare(tx) >= 0 }, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q at position %d", op.text, op.pos)
+	}
+}
+
+func parseFilterLiteral(tok filterToken) (filterValue, error) {
+	switch tok.kind {
+	case tokString:
+		return filterValue{kind: kindString, str: tok.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return filterValue{}, fmt.Errorf("invalid number %q at position %d: amounts are in minor units", tok.text, tok.pos)
+		}
+		return filterValue{kind: kindNumber, num: n}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return filterValue{kind: kindBool, boolean: true}, nil
+		case "false":
+			return filterValue{kind: kindBool, boolean: false}, nil
+		}
+	}
+	return filterValue{}, fmt.Errorf("expected value at position %d, got %q", tok.pos, tok.text)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func filterTestTransactions() []Transaction {
+	return []Transaction{
+		{
+			ID:                "tx_tesco",
+			Amount:            -6250,
+			Currency:          "GBP",
+			Category:          "groceries",
+			Settled:           "2024-01-02T00:00:00Z",
+			IncludeInSpending: true,
+			Merchant:          &Merchant{Name: "Tesco Metro", Online: false},
+		},
+		{
+			ID:                "tx_amazon",
+			Amount:            -1999,
+			Currency:          "GBP",
+			Category:          "shopping",
+			Settled:           "2024-01-03T00:00:00Z",
+			IncludeInSpending: true,
+			Merchant:          &Merchant{Name: "Amazon", Online: true},
+		},
+		{
+			ID:            "tx_declined",
+			Amount:        -10000,
+			Currency:      "GBP",
+			Category:      "groceries",
+			DeclineReason: "INSUFFICIENT_FUNDS",
+			Merchant:      &Merchant{Name: "Tesco Extra"},
+		},
+		{
+			ID:            "tx_euro",
+			Amount:        -850,
+			Currency:      "GBP",
+			LocalAmount:   -1000,
+			LocalCurrency: "EUR",
+			Category:      "eating_out",
+			Description:   "CAFE DE FLORE",
+			Metadata:      map[string]string{"trip": "paris"},
+		},
+	}
+}
+
+func matchedIDs(t *testing.T, expr string) []string {
+	t.Helper()
+
+	filter, err := compileFilter(expr)
+	if err != nil {
+		t.Fatalf("Failed to compile %q: %v", expr, err)
+	}
+
+	var ids []string
+	for _, tx := range filterTransactions(filterTestTransactions(), filter) {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestCompileFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{`amount < -5000 && category == "groceries" && merchant ~ "tesco"`, []string{"tx_tesco", "tx_declined"}},
+		{`amount < -5000 && !declined`, []string{"tx_tesco"}},
+		{`merchant.online`, []string{"tx_amazon"}},
+		{`merchant.online == false && category == "shopping"`, nil},
+		{`category == "shopping" || local_currency == "EUR"`, []string{"tx_amazon", "tx_euro"}},
+		{`(category == "groceries" || category == "shopping") && settled != ""`, []string{"tx_tesco", "tx_amazon"}},
+		{`merchant !~ "^tesco"`, []string{"tx_amazon", "tx_euro"}},
+		{`metadata.trip == 'paris'`, []string{"tx_euro"}},
+		{`pending`, []string{"tx_euro"}},
+	}
+
+	for _, test := range tests {
+		got := matchedIDs(t, test.expr)
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.expr, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`amount`,
+		`amount = 5`,
+		`amount < "five"`,
+		`category < 5`,
+		`unknown == "x"`,
+		`merchant.online > true`,
+		`amount ~ "5"`,
+		`(category == "groceries"`,
+		`category == "groceries" &&`,
+		`category == "unterminated`,
+		`merchant ~ "("`,
+	} {
+		if _, err := compileFilter(expr); err == nil {
+			t.Errorf("Expected error compiling %q, got nil", expr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,11 +21,9 @@ import (
 const (
 	monzoAuthURL    = "https://auth.monzo.com"
 	monzoAPIBaseURL = "https://api.monzo.com"
-	monzoTokenURL   = monzoAPIBaseURL + "/oauth2/token"
 
 	// Timeout durations
 	authTimeout           = 5 * time.Minute
-	tokenExchangeTimeout  = 30 * time.Second
 	serverShutdownTimeout = 5 * time.Second
 )
 
@@ -33,6 +32,13 @@ var (
 	clientSecret string
 	redirectURI  string
 	port         int
+	loginBackup  bool
+
+	// apiBaseURL is the Monzo API every request is sent to and authURL is where
+	// users approve access on login. They can be pointed at a stand-in such as
+	// 'go-monzo mock-server' with --api-base-url and --auth-url.
+	apiBaseURL = monzoAPIBaseURL
+	authURL    = monzoAuthURL
 )
 
 // TokenResponse represents the OAuth token response from Monzo
@@ -63,6 +69,10 @@ This command will:
 4. Exchange the authorization code for an access token
 5. Save the token for future use
 
+Monzo only returns transactions older than 90 days within five minutes of
+logging in. Add --backup to save a full archive straight away, as
+'go-monzo backup' does.
+
 You need to provide your OAuth client credentials, which you can obtain
 from the Monzo Developer Portal at https://developers.monzo.com/`,
 	RunE: runLogin,
@@ -75,6 +85,7 @@ func init() {
 	loginCmd.Flags().StringVar(&clientSecret, "client-secret", os.Getenv("MONZO_CLIENT_SECRET"), "Monzo OAuth client secret (or set MONZO_CLIENT_SECRET)")
 	loginCmd.Flags().StringVar(&redirectURI, "redirect-uri", "", "OAuth redirect URI (default: http://localhost:<port>/callback)")
 	loginCmd.Flags().IntVar(&port, "port", 8080, "Local server port for OAuth callback")
+	loginCmd.Flags().BoolVar(&loginBackup, "backup", false, "Back up all account data straight after logging in")
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
@@ -130,12 +141,14 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("authorization failed: %w", err)
 	case <-time.After(authTimeout):
 		return fmt.Errorf("authorization timed out")
+	case <-cmd.Context().Done():
+		return fmt.Errorf("authorization cancelled")
 	}
 
 	fmt.Println("Authorization received! Exchanging code for token...")
 
 	// Exchange code for token
-	token, err := exchangeCodeForToken(clientID, clientSecret, redirectURI, code)
+	token, err := exchangeCodeForToken(cmd.Context(), clientID, clientSecret, redirectURI, code)
 	if err != nil {
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
@@ -150,6 +163,16 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Token expires in: %d seconds\n", token.ExpiresIn)
 	fmt.Println("\nNote: You may need to approve access in the Monzo app for full API permissions.")
 
+	if loginBackup {
+		// The API refuses requests until access is approved in the app
+		fmt.Print("\nApprove access in the Monzo app, then press Enter to start the backup...")
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+
+		if err := createBackup(cmd.Context(), token.AccessToken, ""); err != nil {
+			return fmt.Errorf("logged in, but the backup failed: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -196,6 +219,11 @@ func startCallbackServer(port int, codeChan chan<- string, errChan chan<- error)
 	return server, nil
 }
 
+// tokenURL returns the OAuth token endpoint of the API
+func tokenURL() string {
+	return apiBaseURL + "/oauth2/token"
+}
+
 func buildAuthURL(clientID, redirectURI string) string {
 	params := url.Values{
 		"client_id":     {clientID},
@@ -203,10 +231,10 @@ func buildAuthURL(clientID, redirectURI string) string {
 		"response_type": {"code"},
 		"state":         {fmt.Sprintf("%d", time.Now().UnixNano())},
 	}
-	return fmt.Sprintf("%s/?%s", monzoAuthURL, params.Encode())
+	return fmt.Sprintf("%s/?%s", authURL, params.Encode())
 }
 
-func exchangeCodeForToken(clientID, clientSecret, redirectURI, code string) (*TokenResponse, error) {
+func exchangeCodeForToken(ctx context.Context, clientID, clientSecret, redirectURI, code string) (*TokenResponse, error) {
 	data := url.Values{
 		"grant_type":    {"authorization_code"},
 		"client_id":     {clientID},
@@ -215,16 +243,16 @@ func exchangeCodeForToken(clientID, clientSecret, redirectURI, code string) (*To
 		"code":          {code},
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), tokenExchangeTimeout)
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", monzoTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -248,7 +276,7 @@ func exchangeCodeForToken(clientID, clientSecret, redirectURI, code string) (*To
 }
 
 // RefreshToken uses the refresh token to obtain a new access token
-func RefreshToken(clientID, clientSecret, refreshToken string) (*TokenResponse, error) {
+func RefreshToken(ctx context.Context, clientID, clientSecret, refreshToken string) (*TokenResponse, error) {
 	data := url.Values{
 		"grant_type":    {"refresh_token"},
 		"client_id":     {clientID},
@@ -256,16 +284,16 @@ func RefreshToken(clientID, clientSecret, refreshToken string) (*TokenResponse,
 		"refresh_token": {refreshToken},
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), tokenExchangeTimeout)
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", monzoTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
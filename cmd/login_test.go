@@ -1,54 +1,81 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
-	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
 )
 
+// useMockAPI points API requests at a mock Monzo API for the rest of the test
+func useMockAPI(t *testing.T) *monzotest.Server {
+	t.Helper()
+
+	mock := monzotest.NewServer()
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	originalBaseURL := apiBaseURL
+	apiBaseURL = server.URL
+	t.Cleanup(func() { apiBaseURL = originalBaseURL })
+
+	return mock
+}
+
 func TestRefreshToken(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "POST" {
-			t.Errorf("Expected POST request, got %s", r.Method)
-		}
+	useMockAPI(t)
 
-		if err := r.ParseForm(); err != nil {
-			t.Errorf("Failed to parse form: %v", err)
-		}
+	token, err := RefreshToken(context.Background(), monzotest.ClientID, monzotest.ClientSecret, monzotest.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh token: %v", err)
+	}
 
-		if r.Form.Get("grant_type") != "refresh_token" {
-			t.Errorf("Expected grant_type=refresh_token, got %s", r.Form.Get("grant_type"))
-		}
+	if token.AccessToken == "" || token.RefreshToken == "" {
+		t.Errorf("Expected new access and refresh tokens, got %+v", token)
+	}
 
-		if r.Form.Get("refresh_token") == "" {
-			t.Errorf("Expected refresh_token to be set")
-		}
+	if token.ExpiresIn != 21600 {
+		t.Errorf("Expected ExpiresIn 21600, got %d", token.ExpiresIn)
+	}
 
-		response := TokenResponse{
-			AccessToken:  "new_access_token",
-			TokenType:    "Bearer",
-			ExpiresIn:    21600,
-			RefreshToken: "new_refresh_token",
-			Scope:        "",
-			UserID:       "user_123",
-		}
+	if _, err := RefreshToken(context.Background(), monzotest.ClientID, monzotest.ClientSecret, "invalid_refresh_token"); err == nil {
+		t.Error("Expected an error refreshing with an invalid refresh token")
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			t.Errorf("Failed to encode response: %v", err)
-		}
-	}))
-	defer server.Close()
+func TestLoadTokenRefreshesExpiredToken(t *testing.T) {
+	useMockAPI(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MONZO_CLIENT_ID", monzotest.ClientID)
+	t.Setenv("MONZO_CLIENT_SECRET", monzotest.ClientSecret)
 
-	// Since RefreshToken uses the constant monzoTokenURL, we need a different approach
-	// We'll test the token refresh by testing the loadToken function with an expired token
-	t.Log("Mock server created at:", server.URL)
+	if err := saveToken(&TokenResponse{AccessToken: "expired_access_token", RefreshToken: monzotest.RefreshToken, ExpiresIn: -100}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	token, err := loadToken(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load token: %v", err)
+	}
+
+	if token.AccessToken == "expired_access_token" {
+		t.Error("Expected the expired token to be refreshed")
+	}
+
+	// The refreshed token is stored and works against the API
+	accounts, err := fetchAccounts(context.Background(), token.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to fetch accounts with refreshed token: %v", err)
+	}
+	if len(accounts.Accounts) == 0 {
+		t.Error("Expected accounts from the mock API")
+	}
 }
 
 func TestStoredTokenSerialization(t *testing.T) {
@@ -130,7 +157,7 @@ func TestLoadTokenWithValidToken(t *testing.T) {
 	}
 
 	// Load the token
-	token, err := loadToken()
+	token, err := loadToken(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to load token: %v", err)
 	}
@@ -197,7 +224,7 @@ func TestLoadTokenWithExpiredTokenNoCredentials(t *testing.T) {
 	}
 
 	// Load the token - should fail because credentials are not set
-	_, err = loadToken()
+	_, err = loadToken(context.Background())
 	if err == nil {
 		t.Error("Expected error when loading expired token without credentials, got nil")
 	}
@@ -246,7 +273,7 @@ func TestLoadTokenWithExpiredTokenNoRefreshToken(t *testing.T) {
 	}
 
 	// Load the token - should fail because there's no refresh token
-	_, err = loadToken()
+	_, err = loadToken(context.Background())
 	if err == nil {
 		t.Error("Expected error when loading expired token without refresh token, got nil")
 	}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	merchantsAccountID string
+	merchantsOffline   bool
+	merchantsSort      string
+	merchantsLimit     int
+	merchantsOutput    string
+)
+
+// MerchantSummary represents a merchant group, such as a chain with several
+// locations, and the spending there
+type MerchantSummary struct {
+	GroupID       string             `json:"group_id"`
+	Name          string             `json:"name"`
+	Logo          string             `json:"logo,omitempty"`
+	Emoji         string             `json:"emoji,omitempty"`
+	Category      string             `json:"category"`
+	Locations     []MerchantLocation `json:"locations"`
+	Currency      string             `json:"currency"`
+	Visits        int                `json:"visits"`
+	TotalSpend    int64              `json:"total_spend"`
+	AverageSpend  int64              `json:"average_spend"`
+	DaysPerVisit  float64            `json:"days_per_visit,omitempty"` // Average days between visits
+	FirstSeen     time.Time          `json:"first_seen"`
+	LastSeen      time.Time          `json:"last_seen"`
+	OnlineVisits  int                `json:"online_visits"`
+	InStoreVisits int                `json:"in_store_visits"`
+	OnlineSpend   int64              `json:"online_spend"`
+	InStoreSpend  int64              `json:"in_store_spend"`
+}
+
+// MerchantLocation represents one merchant ID within a group
+type MerchantLocation struct {
+	MerchantID string `json:"merchant_id"`
+	Name       string `json:"name"`
+	Address    string `json:"address,omitempty"`
+	Online     bool   `json:"online"`
+	Visits     int    `json:"visits"`
+}
+
+var merchantsCmd = &cobra.Command{
+	Use:   "merchants",
+	Short: "Show where you spend, by merchant",
+	Long: `Build a directory of the merchants you've paid from transaction history.
+
+Merchants are grouped by their Monzo group ID, so the branches of a chain are
+counted together. For each merchant the number of visits, how often you visit,
+total and average spend, when it was first and last seen and the split between
+online and in-store payments are shown.
+
+Transactions newer than those already cached are fetched from the API and
+added to the local cache, and the directory is built from the whole cache, so
+it covers history that has aged out of the API. Use --offline to build it from
+the cache alone.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runMerchants,
+}
+
+var merchantsShowCmd = &cobra.Command{
+	Use:   "show <merchant>",
+	Short: "Show a merchant's locations and spending",
+	Long: `Show one merchant from the directory, with each of its locations.
+
+The merchant is given by group ID or by name, case-insensitive.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMerchantsShow,
+}
+
+func init() {
+	rootCmd.AddCommand(merchantsCmd)
+	merchantsCmd.AddCommand(merchantsShowCmd)
+
+	merchantsCmd.PersistentFlags().StringVar(&merchantsAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	merchantsCmd.PersistentFlags().BoolVar(&merchantsOffline, "offline", false, "Build the directory from the local cache without calling the API")
+	merchantsCmd.PersistentFlags().StringVarP(&merchantsOutput, "output", "o", "table", "Output format: table or json")
+	merchantsCmd.Flags().StringVar(&merchantsSort, "sort", "spend", "Sort by spend, visits, recent or name")
+	merchantsCmd.Flags().IntVar(&merchantsLimit, "limit", 0, "Show only this many merchants (0 for all)")
+
+	_ = merchantsCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = merchantsCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+	_ = merchantsCmd.RegisterFlagCompletionFunc("sort", fixedCompletions("spend", "visits", "recent", "name"))
+}
+
+func runMerchants(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(merchantsOutput); err != nil {
+		return err
+	}
+
+	if merchantsLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+
+	merchants, err := loadMerchantDirectory(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := sortMerchants(merchants, merchantsSort); err != nil {
+		return err
+	}
+	if merchantsLimit > 0 && len(merchants) > merchantsLimit {
+		merchants = merchants[:merchantsLimit]
+	}
+
+	if merchantsOutput == "json" {
+		output, err := json.MarshalIndent(merchants, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal merchants: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(merchants) == 0 {
+		fmt.Println("No merchants found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MERCHANT\tCATEGORY\tVISITS\tEVERY\tTOTAL\tAVERAGE\tFIRST\tLAST\tONLINE")
+	for _, merchant := range merchants {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			merchant.Name,
+			merchant.Category,
+			merchant.Visits,
+			formatVisitInterval(merchant.DaysPerVisit),
+			NewMoney(merchant.TotalSpend, merchant.Currency).String(),
+			NewMoney(merchant.AverageSpend, merchant.Currency).String(),
+			merchant.FirstSeen.Format(dateLayout),
+			merchant.LastSeen.Format(dateLayout),
+			formatOnlineShare(merchant))
+	}
+	return w.Flush()
+}
+
+func runMerchantsShow(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(merchantsOutput); err != nil {
+		return err
+	}
+
+	merchants, err := loadMerchantDirectory(cmd)
+	if err != nil {
+		return err
+	}
+
+	merchant := findMerchant(merchants, args[0])
+	if merchant == nil {
+		return fmt.Errorf("no merchant %q found", args[0])
+	}
+
+	if merchantsOutput == "json" {
+		output, err := json.MarshalIndent(merchant, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal merchant: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Printf("%s %s (%s)\n", merchant.Emoji, merchant.Name, merchant.GroupID)
+	fmt.Printf("Category:   %s\n", merchant.Category)
+	fmt.Printf("Visits:     %d, every %s\n", merchant.Visits, formatVisitInterval(merchant.DaysPerVisit))
+	fmt.Printf("Spent:      %s, %s on average\n", NewMoney(merchant.TotalSpend, merchant.Currency).String(), NewMoney(merchant.AverageSpend, merchant.Currency).String())
+	fmt.Printf("Online:     %d visits, %s\n", merchant.OnlineVisits, NewMoney(merchant.OnlineSpend, merchant.Currency).String())
+	fmt.Printf("In store:   %d visits, %s\n", merchant.InStoreVisits, NewMoney(merchant.InStoreSpend, merchant.Currency).String())
+	fmt.Printf("First seen: %s\n", merchant.FirstSeen.Format(dateLayout))
+	fmt.Printf("Last seen:  %s\n\n", merchant.LastSeen.Format(dateLayout))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LOCATION\tADDRESS\tVISITS")
+	for _, location := range merchant.Locations {
+		address := location.Address
+		if location.Online {
+			address = "online"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", location.Name, address, location.Visits)
+	}
+	return w.Flush()
+}
+
+// loadMerchantDirectory refreshes the transaction cache, unless --offline is
+// set, and builds the merchant directory from it
+func loadMerchantDirectory(cmd *cobra.Command) ([]MerchantSummary, error) {
+	if merchantsAccountID == "" {
+		return nil, fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if !merchantsOffline {
+		// Load the stored token
+		token, err := loadToken(cmd.Context())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+		}
+
+		cached, err := loadTransactionCache(merchantsAccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only transactions the cache doesn't have yet are fetched
+		since := transactionCacheSince(cached.Transactions)
+		transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, merchantsAccountID, since, time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		}
+
+		if err := updateTransactionCache(merchantsAccountID, transactions.Transactions); err != nil {
+			return nil, fmt.Errorf("failed to update transaction cache: %w", err)
+		}
+	}
+
+	transactions, err := loadTransactionCache(merchantsAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return nil, err
+	}
+
+	return buildMerchantDirectory(transactions.Transactions), nil
+}
+
+// buildMerchantDirectory summarises spending by merchant group. Only spending
+// is counted, so refunds and declined payments don't count as visits.
+// Merchants without a group ID are grouped by merchant ID.
+func buildMerchantDirectory(transactions []Transaction) []MerchantSummary {
+	groups := make(map[string]*MerchantSummary)
+	names := make(map[string]map[string]int)
+	locations := make(map[string]map[string]*MerchantLocation)
+	var order []string
+
+	for _, tx := range transactions {
+		if tx.Merchant == nil || !tx.IsSpending() {
+			continue
+		}
+		m := tx.Merchant
+
+		groupID := m.GroupID
+		if groupID == "" {
+			groupID = m.ID
+		}
+
+		key := groupID + "|" + tx.Currency
+		summary, ok := groups[key]
+		if !ok {
+			summary = &MerchantSummary{GroupID: groupID, Currency: tx.Currency}
+			groups[key] = summary
+			names[key] = make(map[string]int)
+			locations[key] = make(map[string]*MerchantLocation)
+			order = append(order, key)
+		}
+
+		spent := -tx.Amount
+		created := tx.CreatedTime()
+
+		summary.Visits++
+		summary.TotalSpend += spent
+		if summary.FirstSeen.IsZero() || created.Before(summary.FirstSeen) {
+			summary.FirstSeen = created
+		}
+		if !created.Before(summary.LastSeen) {
+			summary.LastSeen = created
+			summary.Logo, summary.Emoji, summary.Category = m.Logo, m.Emoji, tx.Category
+		}
+		if m.Online {
+			summary.OnlineVisits++
+			summary.OnlineSpend += spent
+		} else {
+			summary.InStoreVisits++
+			summary.InStoreSpend += spent
+		}
+
+		names[key][m.Name]++
+		location, ok := locations[key][m.ID]
+		if !ok {
+			location = &MerchantLocation{MerchantID: m.ID, Name: m.Name, Address: formatMerchantAddress(m.Address), Online: m.Online}
+			locations[key][m.ID] = location
+		}
+		location.Visits++
+	}
+
+	merchants := make([]MerchantSummary, 0, len(order))
+	for _, key := range order {
+		summary := groups[key]
+		summary.AverageSpend = summary.TotalSpend / int64(summary.Visits)
+		if summary.Visits > 1 {
+			summary.DaysPerVisit = summary.LastSeen.Sub(summary.FirstSeen).Hours() / 24 / float64(summary.Visits-1)
+		}
+
+		// Branches can have different names, so the group is named after
+		// the one visited most
+		for name, visits := range names[key] {
+			if most := names[key][summary.Name]; visits > most || (visits == most && name < summary.Name) {
+				summary.Name = name
+			}
+		}
+
+		for _, location := range locations[key] {
+			summary.Locations = append(summary.Locations, *location)
+		}
+		sort.Slice(summary.Locations, func(i, j int) bool {
+			if summary.Locations[i].Visits != summary.Locations[j].Visits {
+				return summary.Locations[i].Visits > summary.Locations[j].Visits
+			}
+			return summary.Locations[i].MerchantID < summary.Locations[j].MerchantID
+		})
+
+		merchants = append(merchants, *summary)
+	}
+	return merchants
+}
+
+// sortMerchants orders the directory by spend, visits, most recently seen or
+// name
+func sortMerchants(merchants []MerchantSummary, by string) error {
+	var less func(a, b MerchantSummary) bool
+	switch by {
+	case "spend":
+		less = func(a, b MerchantSummary) bool { return a.TotalSpend > b.TotalSpend }
+	case "visits":
+		less = func(a, b MerchantSummary) bool { return a.Visits > b.Visits }
+	case "recent":
+		less = func(a, b MerchantSummary) bool { return a.LastSeen.After(b.LastSeen) }
+	case "name":
+		less = func(a, b MerchantSummary) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	default:
+		return fmt.Errorf("invalid --sort value %q: must be spend, visits, recent or name", by)
+	}
+
+	sort.SliceStable(merchants, func(i, j int) bool {
+		if less(merchants[i], merchants[j]) {
+			return true
+		}
+		if less(merchants[j], merchants[i]) {
+			return false
+		}
+		return merchants[i].Name < merchants[j].Name
+	})
+	return nil
+}
+
+// findMerchant returns the merchant with a group ID or name, or nil
+func findMerchant(merchants []MerchantSummary, ref string) *MerchantSummary {
+	for i := range merchants {
+		if merchants[i].GroupID == ref || strings.EqualFold(merchants[i].Name, ref) {
+			return &merchants[i]
+		}
+	}
+	return nil
+}
+
+// formatMerchantAddress formats an address on one line
+func formatMerchantAddress(address *MerchantAddress) string {
+	if address == nil {
+		return ""
+	}
+	return strings.Join(nonEmpty(address.Address, address.City, address.Postcode, address.Country), ", ")
+}
+
+// formatVisitInterval formats the average days between visits
+func formatVisitInterval(days float64) string {
+	if days == 0 {
+		return "-"
+	}
+	if days < 1 {
+		return "daily"
+	}
+	return fmt.Sprintf("%.0fd", days)
+}
+
+// formatOnlineShare formats the share of visits made online
+func formatOnlineShare(merchant MerchantSummary) string {
+	return fmt.Sprintf("%.0f%%", float64(merchant.OnlineVisits)/float64(merchant.Visits)*100)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestBuildMerchantDirectory(t *testing.T) {
+	tesco := &Merchant{ID: "merch_tesco", GroupID: "grp_tesco", Name: "Tesco", Category: "groceries", Address: &MerchantAddress{Address: "1 High Street", City: "London"}}
+	express := &Merchant{ID: "merch_express", GroupID: "grp_tesco", Name: "Tesco Express", Category: "groceries"}
+	online := &Merchant{ID: "merch_tesco_online", GroupID: "grp_tesco", Name: "Tesco", Category: "groceries", Online: true}
+	corner := &Merchant{ID: "merch_corner", Name: "Corner Shop", Category: "groceries"}
+
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-01-01T10:00:00Z", Amount: -3000, Currency: "GBP", IncludeInSpending: true, Merchant: tesco, Category: "groceries"},
+		{ID: "tx_2", Created: "2024-01-05T10:00:00Z", Amount: -1000, Currency: "GBP", IncludeInSpending: true, Merchant: express, Category: "groceries"},
+		{ID: "tx_3", Created: "2024-01-09T10:00:00Z", Amount: -5000, Currency: "GBP", IncludeInSpending: true, Merchant: online, Category: "groceries"},
+		{ID: "tx_4", Created: "2024-01-13T10:00:00Z", Amount: -2000, Currency: "GBP", IncludeInSpending: true, Merchant: tesco, Category: "groceries"},
+		// Refunds and declined payments aren't visits
+		{ID: "tx_5", Created: "2024-01-14T10:00:00Z", Amount: 1000, Currency: "GBP", Merchant: tesco},
+		{ID: "tx_6", Created: "2024-01-15T10:00:00Z", Amount: -9900, Currency: "GBP", IncludeInSpending: true, Merchant: tesco, DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_7", Created: "2024-01-02T10:00:00Z", Amount: -150, Currency: "GBP", IncludeInSpending: true, Merchant: corner, Category: "groceries"},
+		{ID: "tx_8", Created: "2024-01-03T10:00:00Z", Amount: -90000, Currency: "GBP", Description: "Rent"},
+	}
+
+	merchants := buildMerchantDirectory(transactions)
+	if len(merchants) != 2 {
+		t.Fatalf("Expected Tesco and the corner shop, got %+v", merchants)
+	}
+
+	tescoSummary := merchants[0]
+	if tescoSummary.GroupID != "grp_tesco" || tescoSummary.Name != "Tesco" {
+		t.Errorf("Expected branches to be grouped under the most visited name, got %s %s", tescoSummary.GroupID, tescoSummary.Name)
+	}
+	if tescoSummary.Visits != 4 || tescoSummary.TotalSpend != 11000 || tescoSummary.AverageSpend != 2750 {
+		t.Errorf("Unexpected visits or spend: %+v", tescoSummary)
+	}
+	if tescoSummary.OnlineVisits != 1 || tescoSummary.OnlineSpend != 5000 || tescoSummary.InStoreVisits != 3 || tescoSummary.InStoreSpend != 6000 {
+		t.Errorf("Unexpected online and in-store split: %+v", tescoSummary)
+	}
+	if tescoSummary.DaysPerVisit != 4 {
+		t.Errorf("Expected a visit every 4 days, got %v", tescoSummary.DaysPerVisit)
+	}
+	if tescoSummary.FirstSeen.Format(dateLayout) != "2024-01-01" || tescoSummary.LastSeen.Format(dateLayout) != "2024-01-13" {
+		t.Errorf("Unexpected first and last seen: %s, %s", tescoSummary.FirstSeen, tescoSummary.LastSeen)
+	}
+	if len(tescoSummary.Locations) != 3 || tescoSummary.Locations[0].MerchantID != "merch_tesco" || tescoSummary.Locations[0].Address != "1 High Street, London" {
+		t.Errorf("Expected three locations, most visited first, got %+v", tescoSummary.Locations)
+	}
+
+	if merchants[1].GroupID != "merch_corner" {
+		t.Errorf("Expected a merchant without a group to be keyed by its ID, got %s", merchants[1].GroupID)
+	}
+
+	if err := sortMerchants(merchants, "recent"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, merchant := range merchants {
+		names = append(names, merchant.Name)
+	}
+	if fmt.Sprint(names) != "[Tesco Corner Shop]" {
+		t.Errorf("Expected the most recently seen merchant first, got %v", names)
+	}
+
+	if err := sortMerchants(merchants, "price"); err == nil {
+		t.Error("Expected an error for an invalid sort")
+	}
+	if findMerchant(merchants, "corner shop") == nil || findMerchant(merchants, "grp_tesco") == nil {
+		t.Error("Expected merchants to be found by name or group ID")
+	}
+}
+
+func TestTransactionCacheSince(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		expected     time.Time
+	}{
+		{"empty cache", nil, time.Time{}},
+		{"newest transaction", []Transaction{
+			{ID: "tx_1", Created: "2024-03-01T10:00:00Z", Settled: "2024-03-02T10:00:00Z"},
+			{ID: "tx_2", Created: "2024-03-05T10:00:00Z", Settled: "2024-03-06T10:00:00Z"},
+		}, time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{"oldest pending transaction", []Transaction{
+			{ID: "tx_1", Created: "2024-03-01T10:00:00Z", Settled: "2024-03-02T10:00:00Z"},
+			{ID: "tx_2", Created: "2024-03-03T10:00:00Z"},
+			{ID: "tx_3", Created: "2024-03-05T10:00:00Z", Settled: "2024-03-06T10:00:00Z"},
+		}, time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{"declined transactions never settle", []Transaction{
+			{ID: "tx_1", Created: "2024-03-01T10:00:00Z", DeclineReason: "INSUFFICIENT_FUNDS"},
+			{ID: "tx_2", Created: "2024-03-05T10:00:00Z", Settled: "2024-03-06T10:00:00Z"},
+		}, time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if since := transactionCacheSince(tt.transactions); !since.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, since)
+			}
+		})
+	}
+}
+
+func TestLoadMerchantDirectoryGrowsTheCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := useMockAPI(t)
+	if err := saveToken(&TokenResponse{AccessToken: monzotest.AccessToken, RefreshToken: monzotest.RefreshToken, ExpiresIn: 3600}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	originalAccountID, originalOffline := merchantsAccountID, merchantsOffline
+	merchantsAccountID, merchantsOffline = monzotest.PersonalAccountID, false
+	t.Cleanup(func() { merchantsAccountID, merchantsOffline = originalAccountID, originalOffline })
+
+	countPersonal := func() int {
+		count := 0
+		for _, tx := range mock.Transactions {
+			if tx.AccountID == monzotest.PersonalAccountID {
+				count++
+			}
+		}
+		return count
+	}
+
+	merchantsCmd.SetContext(context.Background())
+	for run := 1; run <= 2; run++ {
+		if _, err := loadMerchantDirectory(merchantsCmd); err != nil {
+			t.Fatalf("Run %d: failed to load merchant directory: %v", run, err)
+		}
+
+		cached, err := loadTransactionCache(monzotest.PersonalAccountID)
+		if err != nil {
+			t.Fatalf("Run %d: failed to load cache: %v", run, err)
+		}
+		if expected := countPersonal(); len(cached.Transactions) != expected {
+			t.Errorf("Run %d: expected %d cached transactions, got %d", run, expected, len(cached.Transactions))
+		}
+
+		mock.AddTransaction(monzotest.Transaction{
+			AccountID: monzotest.PersonalAccountID, Created: time.Now().UTC().Format(time.RFC3339Nano),
+			Description: "Coffee", Amount: -350, Settled: time.Now().UTC().Format(time.RFC3339Nano),
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money represents an amount in the minor units of an ISO 4217 currency,
+// e.g. pence for GBP
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// currencyInfo describes how amounts in a currency are written
+type currencyInfo struct {
+	Exponent int    // Number of decimal places
+	Symbol   string // Symbol written before the amount, if any
+}
+
+// currencies is the table of known currencies. Unknown currencies are
+// assumed to use two decimal places and are written with their code.
+var currencies = map[string]currencyInfo{
+	"AED": {Exponent: 2},
+	"AUD": {Exponent: 2, Symbol: "A$"},
+	"BHD": {Exponent: 3},
+	"BRL": {Exponent: 2, Symbol: "R$"},
+	"CAD": {Exponent: 2, Symbol: "C$"},
+	"CHF": {Exponent: 2},
+	"CLP": {Exponent: 0},
+	"CNY": {Exponent: 2},
+	"CZK": {Exponent: 2},
+	"DKK": {Exponent: 2},
+	"EUR": {Exponent: 2, Symbol: "€"},
+	"GBP": {Exponent: 2, Symbol: "£"},
+	"HKD": {Exponent: 2, Symbol: "HK$"},
+	"HUF": {Exponent: 2},
+	"IDR": {Exponent: 2},
+	"ILS": {Exponent: 2, Symbol: "₪"},
+	"INR": {Exponent: 2, Symbol: "₹"},
+	"ISK": {Exponent: 0},
+	"JOD": {Exponent: 3},
+	"JPY": {Exponent: 0, Symbol: "¥"},
+	"KRW": {Exponent: 0, Symbol: "₩"},
+	"KWD": {Exponent: 3},
+	"MXN": {Exponent: 2},
+	"NOK": {Exponent: 2},
+	"NZD": {Exponent: 2, Symbol: "NZ$"},
+	"OMR": {Exponent: 3},
+	"PLN": {Exponent: 2},
+	"SEK": {Exponent: 2},
+	"SGD": {Exponent: 2, Symbol: "S$"},
+	"THB": {Exponent: 2, Symbol: "฿"},
+	"TND": {Exponent: 3},
+	"TRY": {Exponent: 2, Symbol: "₺"},
+	"UGX": {Exponent: 0},
+	"USD": {Exponent: 2, Symbol: "$"},
+	"VND": {Exponent: 0, Symbol: "₫"},
+	"XAF": {Exponent: 0},
+	"XOF": {Exponent: 0},
+	"ZAR": {Exponent: 2},
+}
+
+// NewMoney returns an amount in minor units of a currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// currencyExponent returns the number of decimal places used by a currency
+func currencyExponent(currency string) int {
+	if info, ok := currencies[strings.ToUpper(currency)]; ok {
+		return info.Exponent
+	}
+	return 2
+}
+
+// Exponent returns the number of decimal places used by the currency
+func (m Money) Exponent() int {
+	return currencyExponent(m.Currency)
+}
+
+// Major returns the amount in major units, e.g. pounds rather than pence
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(m.Exponent())
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: cannot add %s to %s", ErrCurrencyMismatch, other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of two amounts in the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: cannot subtract %s from %s", ErrCurrencyMismatch, other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// String formats the amount for display, e.g. "£1,234.50", "-€5.00" or
+// "12.50 CHF" for currencies without a symbol
+func (m Money) String() string {
+	exponent := m.Exponent()
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(math.Pow10(exponent))
+	number := groupThousands(strconv.FormatInt(amount/scale, 10))
+	if exponent > 0 {
+		number += fmt.Sprintf(".%0*d", exponent, amount%scale)
+	}
+
+	if info, ok := currencies[m.Currency]; ok && info.Symbol != "" {
+		return sign + info.Symbol + number
+	}
+	if m.Currency == "" {
+		return sign + number
+	}
+	return sign + number + " " + m.Currency
+}
+
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// ParseMoney parses an amount entered by a user, such as "£12.50", "12.5 GBP",
+// "EUR 1,000" or "12.50". defaultCurrency is used when the input doesn't
+// name a currency.
+func ParseMoney(value, defaultCurrency string) (Money, error) {
+	input := strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(input, "-") {
+		negative = true
+		input = strings.TrimSpace(input[1:])
+	}
+
+	currency := ""
+
+	// Currency code before or after the number
+	if fields := strings.Fields(input); len(fields) == 2 {
+		switch {
+		case isCurrencyCode(fields[0]):
+			currency, input = strings.ToUpper(fields[0]), fields[1]
+		case isCurrencyCode(fields[1]):
+			currency, input = strings.ToUpper(fields[1]), fields[0]
+		default:
+			return Money{}, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+
+	// Currency symbol before the number
+	for code, info := range currencies {
+		if info.Symbol == "" || !strings.HasPrefix(input, info.Symbol) {
+			continue
+		}
+		if currency != "" && currency != code {
+			return Money{}, fmt.Errorf("invalid amount %q: conflicting currencies", value)
+		}
+		currency = code
+		input = strings.TrimPrefix(input, info.Symbol)
+		break
+	}
+
+	if strings.HasPrefix(input, "-") && !negative {
+		negative = true
+		input = input[1:]
+	}
+
+	if currency == "" {
+		currency = strings.ToUpper(defaultCurrency)
+	}
+	if currency == "" {
+		return Money{}, fmt.Errorf("invalid amount %q: no currency given", value)
+	}
+
+	amount, err := parseMinorUnits(strings.ReplaceAll(input, ",", ""), currencyExponent(currency))
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// parseMinorUnits parses an unsigned decimal number into minor units
+func parseMinorUnits(number string, exponent int) (int64, error) {
+	whole, fraction, hasFraction := strings.Cut(number, ".")
+	if hasFraction && (len(fraction) == 0 || len(fraction) > exponent) {
+		return 0, fmt.Errorf("expected at most %d decimal places", exponent)
+	}
+
+	major, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("not a number")
+	}
+
+	var minor uint64
+	if hasFraction {
+		minor, err = strconv.ParseUint(fraction, 10, 63)
+		if err != nil {
+			return 0, fmt.Errorf("not a number")
+		}
+		minor *= uint64(math.Pow10(exponent - len(fraction)))
+	}
+
+	scale := uint64(math.Pow10(exponent))
+	if major > math.MaxInt64/scale {
+		return 0, fmt.Errorf("amount too large")
+	}
+
+	return int64(major*scale + minor), nil
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money    Money
+		expected string
+	}{
+		{NewMoney(1250, "GBP"), "£12.50"},
+		{NewMoney(-5, "GBP"), "-£0.05"},
+		{NewMoney(123456789, "GBP"), "£1,234,567.89"},
+		{NewMoney(1950, "JPY"), "¥1,950"},
+		{NewMoney(12345, "KWD"), "12.345 KWD"},
+		{NewMoney(1000, "chf"), "10.00 CHF"},
+		{NewMoney(1000, "XYZ"), "10.00 XYZ"},
+		{NewMoney(0, "EUR"), "€0.00"},
+	}
+
+	for _, test := range tests {
+		if got := test.money.String(); got != test.expected {
+			t.Errorf("Expected %+v to format as %q, got %q", test.money, test.expected, got)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Money
+	}{
+		{"£12.50", NewMoney(1250, "GBP")},
+		{"12.5 GBP", NewMoney(1250, "GBP")},
+		{"gbp 12.5", NewMoney(1250, "GBP")},
+		{"200", NewMoney(20000, "GBP")},
+		{"€1,000", NewMoney(100000, "EUR")},
+		{"-£5", NewMoney(-500, "GBP")},
+		{"£-5", NewMoney(-500, "GBP")},
+		{"1950 JPY", NewMoney(1950, "JPY")},
+		{"¥1950", NewMoney(1950, "JPY")},
+		{"A$3.20", NewMoney(320, "AUD")},
+		{"$3.20", NewMoney(320, "USD")},
+		{"0.07", NewMoney(7, "GBP")},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMoney(test.input, "GBP")
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("ParseMoney(%q) = %+v, expected %+v", test.input, got, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1.234", "12.5 JPY", "1.", "£12 EUR", "12 50", "--5"} {
+		if _, err := ParseMoney(input, "GBP"); err == nil {
+			t.Errorf("Expected error for ParseMoney(%q), got nil", input)
+		}
+	}
+
+	if _, err := ParseMoney("12.50", ""); err == nil {
+		t.Error("Expected error when no currency is given, got nil")
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1000, "GBP").Add(NewMoney(250, "GBP"))
+	if err != nil || sum != NewMoney(1250, "GBP") {
+		t.Errorf("Expected £12.50, got %v (error: %v)", sum, err)
+	}
+
+	difference, err := NewMoney(1000, "GBP").Sub(NewMoney(2500, "GBP"))
+	if err != nil || difference != NewMoney(-1500, "GBP") {
+		t.Errorf("Expected -£15.00, got %v (error: %v)", difference, err)
+	}
+
+	if _, err := NewMoney(1000, "GBP").Add(NewMoney(1000, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch adding GBP to EUR, got %v", err)
+	}
+
+	if _, err := NewMoney(1000, "GBP").Sub(NewMoney(1000, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch subtracting EUR from GBP, got %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const networthFile = "networth.json"
+
+var (
+	networthOutput   string
+	networthNoSave   bool
+	networthCurrency string
+)
+
+// NetWorthItem represents one account, pot, asset or liability counted in a
+// net worth snapshot
+type NetWorthItem struct {
+	Kind     string `json:"kind"` // account, pot, savings, asset or liability
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Amount   int64  `json:"amount"` // Minor units, negative for liabilities
+	Currency string `json:"currency"`
+}
+
+// NetWorthSnapshot represents net worth on a day
+type NetWorthSnapshot struct {
+	Date   string          `json:"date"` // YYYY-MM-DD
+	Items  []NetWorthItem  `json:"items"`
+	Totals []CurrencyTotal `json:"totals"`
+}
+
+// NetWorthHistory represents the snapshots stored in ~/.go-monzo/networth.json
+type NetWorthHistory struct {
+	Snapshots []NetWorthSnapshot `json:"snapshots"`
+}
+
+// NetWorthChange represents the change in net worth since an earlier snapshot
+type NetWorthChange struct {
+	Since    string `json:"since"`
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// NetWorthReport represents the current net worth and how it has changed
+type NetWorthReport struct {
+	NetWorthSnapshot
+	Changes []NetWorthChange `json:"changes"`
+}
+
+var networthCmd = &cobra.Command{
+	Use:   "networth",
+	Short: "Show net worth across accounts, pots and other assets",
+	Long: `Show your net worth: the balances of every open account and pot, plus any
+assets and liabilities held outside Monzo.
+
+Assets and liabilities are declared in ~/.go-monzo/config.json with amounts in
+minor units. Liability amounts are positive and are subtracted:
+
+  {
+    "assets": [{"name": "Stocks ISA", "amount": 1500000, "currency": "GBP"}],
+    "liabilities": [{"name": "Car loan", "amount": 450000, "currency": "GBP"}]
+  }
+
+Savings that the balance endpoint reports but that aren't in a listed pot,
+such as flexible savings, are shown as savings.
+
+Each run stores a snapshot for the day in ~/.go-monzo/networth.json, replacing
+any earlier snapshot from the same day, and shows the change since the
+previous snapshot and since the first. Use --no-save to leave the history
+unchanged, and 'go-monzo networth history' to see the trend.
+
+Totals in different currencies are never added together.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runNetworth,
+}
+
+var networthHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show stored net worth snapshots",
+	Long: `Show the net worth snapshots stored by 'go-monzo networth', with the change
+from each snapshot to the next, for one currency.`,
+	RunE: runNetworthHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(networthCmd)
+	networthCmd.AddCommand(networthHistoryCmd)
+
+	networthCmd.PersistentFlags().StringVarP(&networthOutput, "output", "o", "table", "Output format: table or json")
+	networthCmd.Flags().BoolVar(&networthNoSave, "no-save", false, "Don't store a snapshot")
+	networthHistoryCmd.Flags().StringVar(&networthCurrency, "currency", "GBP", "Currency of the totals to show")
+
+	_ = networthCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+	_ = networthHistoryCmd.RegisterFlagCompletionFunc("currency", completeCurrencies)
+}
+
+func runNetworth(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(networthOutput); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := validateManualHoldings(config); err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	items, err := fetchNetWorthItems(cmd.Context(), token.AccessToken)
+	if err != nil {
+		return err
+	}
+	items = append(items, manualNetWorthItems(config)...)
+
+	snapshot := newNetWorthSnapshot(time.Now().Format("2006-01-02"), items)
+
+	history, err := loadNetWorthHistory()
+	if err != nil {
+		return err
+	}
+
+	report := &NetWorthReport{NetWorthSnapshot: snapshot, Changes: netWorthChanges(history.Snapshots, snapshot)}
+
+	if !networthNoSave {
+		history.add(snapshot)
+		if err := saveStateFile(networthFile, history); err != nil {
+			return fmt.Errorf("failed to save net worth snapshot: %w", err)
+		}
+	}
+
+	if networthOutput == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal net worth: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tAMOUNT")
+	for _, item := range report.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.Kind, item.Name, NewMoney(item.Amount, item.Currency).String())
+	}
+	for _, total := range report.Totals {
+		fmt.Fprintf(w, "NET WORTH\t%s\t%s\n", total.Currency, NewMoney(total.Amount, total.Currency).String())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Changes) > 0 {
+		fmt.Println()
+	}
+	for _, change := range report.Changes {
+		fmt.Printf("Change since %s: %s\n", change.Since, formatSignedMoney(change.Amount, change.Currency))
+	}
+	return nil
+}
+
+func runNetworthHistory(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(networthOutput); err != nil {
+		return err
+	}
+
+	history, err := loadNetWorthHistory()
+	if err != nil {
+		return err
+	}
+
+	if networthOutput == "json" {
+		output, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal net worth history: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(history.Snapshots) == 0 {
+		fmt.Println("No net worth snapshots yet. Run 'go-monzo networth' to take one.")
+		return nil
+	}
+
+	currency := strings.ToUpper(networthCurrency)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tNET WORTH\tCHANGE")
+	var values []int64
+	for i, snapshot := range history.Snapshots {
+		total := snapshot.total(currency)
+		change := ""
+		if i > 0 {
+			change = formatSignedMoney(total-values[i-1], currency)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", snapshot.Date, NewMoney(total, currency).String(), change)
+		values = append(values, total)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", sparkline(values))
+	return nil
+}
+
+// fetchNetWorthItems fetches the balances and pots of every open account
+func fetchNetWorthItems(ctx context.Context, accessToken string) ([]NetWorthItem, error) {
+	accounts, err := openAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	perAccount := make([][]NetWorthItem, len(accounts))
+	err = forEachAccount(ctx, accounts, func(ctx context.Context, i int, account Account) error {
+		balance, err := fetchBalance(ctx, accessToken, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch balance: %w", err)
+		}
+
+		pots, err := fetchPots(ctx, accessToken, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch pots: %w", err)
+		}
+
+		perAccount[i] = accountNetWorthItems(account, balance, openPots(pots.Pots))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []NetWorthItem
+	for _, accountItems := range perAccount {
+		items = append(items, accountItems...)
+	}
+	return items, nil
+}
+
+// accountNetWorthItems returns the items for an account and its pots. Savings
+// included in the account's total balance but not held in a listed pot are
+// returned as a savings item.
+func accountNetWorthItems(account Account, balance *BalanceResponse, pots []Pot) []NetWorthItem {
+	items := []NetWorthItem{{Kind: "account", ID: account.ID, Name: account.Name(), Amount: balance.Balance, Currency: balance.Currency}}
+
+	inPots := int64(0)
+	for _, pot := range pots {
+		items = append(items, NetWorthItem{Kind: "pot", ID: pot.ID, Name: pot.Name, Amount: pot.Balance, Currency: pot.Currency})
+		if pot.Currency == balance.Currency {
+			inPots += pot.Balance
+		}
+	}
+
+	total := balance.TotalBalance
+	if balance.BalanceIncludingFlexibleSavings > total {
+		total = balance.BalanceIncludingFlexibleSavings
+	}
+	if other := total - balance.Balance - inPots; other > 0 {
+		items = append(items, NetWorthItem{Kind: "savings", ID: account.ID, Name: account.Name() + " savings", Amount: other, Currency: balance.Currency})
+	}
+
+	return items
+}
+
+// validateManualHoldings checks the assets and liabilities declared in the
+// config have a name, a known currency and an amount that isn't negative
+func validateManualHoldings(config *Config) error {
+	for _, group := range []struct {
+		kind     string
+		holdings []ManualHolding
+	}{
+		{"asset", config.Assets},
+		{"liability", config.Liabilities},
+	} {
+		for i, holding := range group.holdings {
+			if holding.Name == "" {
+				return fmt.Errorf("%s %d in config.json has no name", group.kind, i+1)
+			}
+			if holding.Currency == "" {
+				return fmt.Errorf("%s %q has no currency", group.kind, holding.Name)
+			}
+			if _, ok := currencies[strings.ToUpper(holding.Currency)]; !ok {
+				return fmt.Errorf("%s %q has unknown currency %q", group.kind, holding.Name, holding.Currency)
+			}
+			if holding.Amount < 0 {
+				return fmt.Errorf("%s %q has a negative amount: amounts are positive, and liabilities are subtracted", group.kind, holding.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// manualNetWorthItems returns the assets and liabilities declared in the config
+func manualNetWorthItems(config *Config) []NetWorthItem {
+	var items []NetWorthItem
+	for _, asset := range config.Assets {
+		items = append(items, NetWorthItem{Kind: "asset", Name: asset.Name, Amount: asset.Amount, Currency: strings.ToUpper(asset.Currency)})
+	}
+	for _, liability := range config.Liabilities {
+		items = append(items, NetWorthItem{Kind: "liability", Name: liability.Name, Amount: -liability.Amount, Currency: strings.ToUpper(liability.Currency)})
+	}
+	return items
+}
+
+// newNetWorthSnapshot totals items by currency
+func newNetWorthSnapshot(date string, items []NetWorthItem) NetWorthSnapshot {
+	totals := make(map[string]*CurrencyTotal)
+	for _, item := range items {
+		addCurrencyTotal(totals, item.Currency, item.Amount)
+	}
+	return NetWorthSnapshot{Date: date, Items: items, Totals: sortedCurrencyTotals(totals)}
+}
+
+// total returns the net worth in a currency
+func (s NetWorthSnapshot) total(currency string) int64 {
+	for _, total := range s.Totals {
+		if total.Currency == currency {
+			return total.Amount
+		}
+	}
+	return 0
+}
+
+func loadNetWorthHistory() (*NetWorthHistory, error) {
+	var history NetWorthHistory
+	if err := loadStateFile(networthFile, &history); err != nil {
+		return nil, fmt.Errorf("failed to load net worth history: %w", err)
+	}
+	return &history, nil
+}
+
+// add stores a snapshot, replacing any snapshot from the same day
+func (h *NetWorthHistory) add(snapshot NetWorthSnapshot) {
+	for i := range h.Snapshots {
+		if h.Snapshots[i].Date == snapshot.Date {
+			h.Snapshots[i] = snapshot
+			return
+		}
+	}
+	h.Snapshots = append(h.Snapshots, snapshot)
+	sort.Slice(h.Snapshots, func(i, j int) bool { return h.Snapshots[i].Date < h.Snapshots[j].Date })
+}
+
+// netWorthChanges returns the change in each currency since the latest
+// earlier snapshot and since the first snapshot
+func netWorthChanges(snapshots []NetWorthSnapshot, current NetWorthSnapshot) []NetWorthChange {
+	var earlier []NetWorthSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Date < current.Date {
+			earlier = append(earlier, snapshot)
+		}
+	}
+	if len(earlier) == 0 {
+		return nil
+	}
+
+	baselines := []NetWorthSnapshot{earlier[len(earlier)-1]}
+	if len(earlier) > 1 {
+		baselines = append(baselines, earlier[0])
+	}
+
+	var changes []NetWorthChange
+	for _, baseline := range baselines {
+		for _, total := range current.Totals {
+			changes = append(changes, NetWorthChange{
+				Since:    baseline.Date,
+				Currency: total.Currency,
+				Amount:   total.Amount - baseline.total(total.Currency),
+			})
+		}
+	}
+	return changes
+}
+
+// formatSignedMoney formats an amount with an explicit + for increases
+func formatSignedMoney(amount int64, currency string) string {
+	if amount > 0 {
+		return "+" + NewMoney(amount, currency).String()
+	}
+	return NewMoney(amount, currency).String()
+}
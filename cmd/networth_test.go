@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestAccountNetWorthItems(t *testing.T) {
+	account := Account{ID: "acc_1", Description: "Personal"}
+	balance := &BalanceResponse{Balance: 10000, TotalBalance: 15000, BalanceIncludingFlexibleSavings: 40000, Currency: "GBP"}
+	pots := []Pot{{ID: "pot_1", Name: "Holiday", Balance: 5000, Currency: "GBP"}}
+
+	items := accountNetWorthItems(account, balance, pots)
+	if len(items) != 3 {
+		t.Fatalf("Expected account, pot and savings items, got %+v", items)
+	}
+	if items[2].Kind != "savings" || items[2].Amount != 25000 {
+		t.Errorf("Expected 25000 of unlisted savings, got %+v", items[2])
+	}
+
+	snapshot := newNetWorthSnapshot("2024-06-01", append(items, manualNetWorthItems(&Config{
+		Assets:      []ManualHolding{{Name: "ISA", Amount: 100000, Currency: "gbp"}},
+		Liabilities: []ManualHolding{{Name: "Loan", Amount: 30000, Currency: "GBP"}},
+	})...))
+	if total := snapshot.total("GBP"); total != 110000 {
+		t.Errorf("Expected net worth of 110000, got %d", total)
+	}
+}
+
+func TestValidateManualHoldings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"valid", Config{
+			Assets:      []ManualHolding{{Name: "ISA", Amount: 100000, Currency: "gbp"}},
+			Liabilities: []ManualHolding{{Name: "Loan", Amount: 30000, Currency: "GBP"}},
+		}, true},
+		{"no holdings", Config{}, true},
+		{"missing name", Config{Assets: []ManualHolding{{Amount: 100000, Currency: "GBP"}}}, false},
+		{"missing currency", Config{Assets: []ManualHolding{{Name: "ISA", Amount: 100000}}}, false},
+		{"unknown currency", Config{Assets: []ManualHolding{{Name: "ISA", Amount: 100000, Currency: "XYZ"}}}, false},
+		{"negative asset", Config{Assets: []ManualHolding{{Name: "ISA", Amount: -100000, Currency: "GBP"}}}, false},
+		{"negative liability", Config{Liabilities: []ManualHolding{{Name: "Loan", Amount: -30000, Currency: "GBP"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateManualHoldings(&tt.config); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestNetWorthHistoryChanges(t *testing.T) {
+	snapshot := func(date string, amount int64) NetWorthSnapshot {
+		return newNetWorthSnapshot(date, []NetWorthItem{{Kind: "account", Amount: amount, Currency: "GBP"}})
+	}
+
+	history := &NetWorthHistory{}
+	history.add(snapshot("2024-06-02", 2000))
+	history.add(snapshot("2024-06-01", 1000))
+	history.add(snapshot("2024-06-02", 2500))
+
+	if len(history.Snapshots) != 2 || history.Snapshots[0].Date != "2024-06-01" || history.Snapshots[1].total("GBP") != 2500 {
+		t.Fatalf("Expected two ordered snapshots with the same day replaced, got %+v", history.Snapshots)
+	}
+
+	changes := netWorthChanges(history.Snapshots, snapshot("2024-06-03", 4000))
+	if len(changes) != 2 {
+		t.Fatalf("Expected changes since the previous and first snapshots, got %+v", changes)
+	}
+	if changes[0].Since != "2024-06-02" || changes[0].Amount != 1500 {
+		t.Errorf("Unexpected change since previous snapshot: %+v", changes[0])
+	}
+	if changes[1].Since != "2024-06-01" || changes[1].Amount != 3000 {
+		t.Errorf("Unexpected change since first snapshot: %+v", changes[1])
+	}
+
+	// Today's snapshot isn't compared with itself
+	if changes := netWorthChanges(history.Snapshots, snapshot("2024-06-01", 1200)); changes != nil {
+		t.Errorf("Expected no changes without an earlier snapshot, got %+v", changes)
+	}
+}
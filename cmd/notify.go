@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Alert represents a triggered alert rule
+type Alert struct {
+	Rule          string    `json:"rule"`
+	Type          string    `json:"type"`
+	Message       string    `json:"message"`
+	AccountID     string    `json:"account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// Notifier represents a destination for alerts in ~/.go-monzo/alerts.json
+type Notifier struct {
+	Type     string   `json:"type"`                // stdout, exec, webhook or feed
+	Command  []string `json:"command,omitempty"`   // Program and arguments for exec
+	URL      string   `json:"url,omitempty"`       // Endpoint for webhook, or link for feed items
+	ImageURL string   `json:"image_url,omitempty"` // Icon for feed items, required by Monzo
+}
+
+// validate checks the notifier has the settings its type needs
+func (n Notifier) validate() error {
+	switch n.Type {
+	case "stdout":
+		return nil
+	case "exec":
+		if len(n.Command) == 0 {
+			return fmt.Errorf("exec notifier requires a command")
+		}
+	case "webhook":
+		if n.URL == "" {
+			return fmt.Errorf("webhook notifier requires a url")
+		}
+	case "feed":
+		// Monzo rejects feed items without an image
+		if n.ImageURL == "" {
+			return fmt.Errorf("feed notifier requires an image_url")
+		}
+	default:
+		return fmt.Errorf("unknown notifier type %q: must be stdout, exec, webhook or feed", n.Type)
+	}
+	return nil
+}
+
+// notify delivers an alert. accessToken is used for Monzo feed items.
+func (n Notifier) notify(ctx context.Context, alert Alert, accessToken string) error {
+	switch n.Type {
+	case "stdout":
+		fmt.Printf("%s  %s: %s\n", alert.Time.Local().Format("2006-01-02 15:04:05"), alert.Rule, alert.Message)
+		return nil
+	case "exec":
+		return execNotify(ctx, n.Command, alert)
+	case "webhook":
+		return webhookNotify(ctx, n.URL, alert)
+	case "feed":
+		return createFeedItem(ctx, accessToken, alert.AccountID, alert.Rule, alert.Message, n.ImageURL, n.URL)
+	}
+	return fmt.Errorf("unknown notifier type %q", n.Type)
+}
+
+// execNotify runs a command for an alert. The alert is passed as JSON on
+// stdin and its main fields as MONZO_ALERT_* environment variables.
+func execNotify(ctx context.Context, command []string, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	c := exec.CommandContext(ctx, command[0], command[1:]...)
+	c.Stdin = bytes.NewReader(data)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(),
+		"MONZO_ALERT_RULE="+alert.Rule,
+		"MONZO_ALERT_TYPE="+alert.Type,
+		"MONZO_ALERT_MESSAGE="+alert.Message,
+		"MONZO_ALERT_ACCOUNT_ID="+alert.AccountID,
+		"MONZO_ALERT_TRANSACTION_ID="+alert.TransactionID,
+	)
+
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("alert command failed: %w", err)
+	}
+	return nil
+}
+
+// webhookNotify posts an alert as JSON to a URL
+func webhookNotify(ctx context.Context, webhookURL string, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// createFeedItem adds a basic item to the account's feed in the Monzo app
+func createFeedItem(ctx context.Context, accessToken, accountID, title, body, imageURL, linkURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	data := url.Values{}
+	data.Set("account_id", accountID)
+	data.Set("type", "basic")
+	data.Set("params[title]", title)
+	data.Set("params[body]", body)
+	data.Set("params[image_url]", imageURL)
+	if linkURL != "" {
+		data.Set("url", linkURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiBaseURL+"/feed", strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// incomeHistoryMonths is how much history is searched for recurring income
+	incomeHistoryMonths = 4
+
+	// incomeTolerance is the variation allowed between income payments, which
+	// is larger than for subscriptions to allow for overtime and tax changes
+	incomeTolerance = 0.25
+)
+
+// Budget and report periods
+const (
+	periodMonth    = "month"
+	periodPayCycle = "pay-cycle"
+)
+
+var (
+	paydayAccountID string
+	paydayPayer     string
+	paydayOutput    string
+)
+
+// PayCycle represents the period between paydays
+type PayCycle struct {
+	Salary        RecurringPayment `json:"salary"`
+	PreviousStart time.Time        `json:"previous_start"` // Midnight at the start of the payday before the last
+	Start         time.Time        `json:"start"`          // Midnight at the start of the last payday
+	End           time.Time        `json:"end"`            // Midnight at the start of the next expected payday
+}
+
+// PaydayReport represents the current pay cycle and the spending in it
+type PaydayReport struct {
+	AccountID string `json:"account_id"`
+	PayCycle
+	DaysUntilPayday int                `json:"days_until_payday"`
+	Spent           int64              `json:"spent"`
+	SpendCount      int                `json:"spend_count"`
+	Income          []RecurringPayment `json:"income"`
+}
+
+var paydayCmd = &cobra.Command{
+	Use:   "payday",
+	Short: "Show the last and next payday and spending since payday",
+	Long: `Show when you were last paid, when the next payment is expected and how
+much has been spent since payday.
+
+Recurring income is detected from incoming payments from the same payer at a
+weekly, fortnightly or monthly cadence, over the last few months. The payer is
+the name of the sender of a bank transfer, or the description of other
+payments. The largest recurring income is treated as your salary, unless
+--payer or salary_payer in ~/.go-monzo/config.json names another payer.
+
+The same pay cycle is used by 'go-monzo budget status --period pay-cycle' and
+the report commands' --period pay-cycle.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runPayday,
+}
+
+func init() {
+	rootCmd.AddCommand(paydayCmd)
+
+	paydayCmd.Flags().StringVar(&paydayAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	paydayCmd.Flags().StringVar(&paydayPayer, "payer", "", "Name of the payer of your salary (overrides salary_payer in config)")
+	paydayCmd.Flags().StringVarP(&paydayOutput, "output", "o", "table", "Output format: table or json")
+
+	_ = paydayCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = paydayCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+}
+
+func runPayday(cmd *cobra.Command, args []string) error {
+	if paydayAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(paydayOutput); err != nil {
+		return err
+	}
+
+	payer := paydayPayer
+	if payer == "" {
+		config, err := LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		payer = config.SalaryPayer
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	now := time.Now()
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, paydayAccountID, now.AddDate(0, -incomeHistoryMonths, 0), time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	income := detectIncome(transactions.Transactions, now)
+	cycle, err := detectPayCycle(income, payer, now)
+	if err != nil {
+		return err
+	}
+
+	report := &PaydayReport{
+		AccountID:       paydayAccountID,
+		PayCycle:        *cycle,
+		DaysUntilPayday: int(math.Round(cycle.End.Sub(startOfDay(now)).Hours() / 24)),
+		Income:          income,
+	}
+	for _, tx := range transactions.Transactions {
+		if tx.IsSpending() && tx.Currency == cycle.Salary.Currency && !tx.CreatedTime().Before(cycle.Start) {
+			report.Spent += -tx.Amount
+			report.SpendCount++
+		}
+	}
+
+	if paydayOutput == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal payday: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	salary := report.Salary
+	fmt.Printf("Salary:       %s (%s)\n", salary.Name, salary.Cadence)
+	fmt.Printf("Last payday:  %s, %s\n", report.Start.Format(dateLayout), NewMoney(salary.LastAmount, salary.Currency).String())
+	fmt.Printf("Next payday:  %s, in %d days\n", report.End.Format(dateLayout), report.DaysUntilPayday)
+	fmt.Printf("Spent since payday: %s (%d transactions)\n", NewMoney(report.Spent, salary.Currency).String(), report.SpendCount)
+
+	if len(report.Income) < 2 {
+		return nil
+	}
+
+	fmt.Println("\nRecurring income:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAYER\tCADENCE\tPAYMENTS\tLAST\tAMOUNT\tNEXT")
+	for _, income := range report.Income {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			income.Name,
+			income.Cadence,
+			income.Count,
+			income.LastDate.Format(dateLayout),
+			NewMoney(income.ExpectedAmount, income.Currency).String(),
+			income.NextExpected.Format(dateLayout))
+	}
+	return w.Flush()
+}
+
+// payerName returns the name of the sender of an incoming payment
+func payerName(tx Transaction) string {
+	if tx.Counterparty != nil && tx.Counterparty.Name != "" {
+		return tx.Counterparty.Name
+	}
+	return tx.MerchantName()
+}
+
+// detectIncome finds recurring incoming payments, grouped by payer, largest
+// first
+func detectIncome(transactions []Transaction, now time.Time) []RecurringPayment {
+	groups := make(map[string][]Transaction)
+	for _, tx := range transactions {
+		if tx.Amount <= 0 || tx.IsDeclined() || tx.IsPotTransfer() {
+			continue
+		}
+		key := strings.ToUpper(strings.TrimSpace(payerName(tx)))
+		groups[key] = append(groups[key], tx)
+	}
+
+	income := detectRecurring(groups, payerName, incomeTolerance, now)
+	sort.SliceStable(income, func(i, j int) bool { return income[i].ExpectedAmount > income[j].ExpectedAmount })
+	return income
+}
+
+// detectPayCycle picks the salary from the recurring income and returns the
+// pay cycle it is part of. The salary is the income from the named payer or,
+// if payer is empty, the largest. Annual income doesn't mark paydays.
+func detectPayCycle(income []RecurringPayment, payer string, now time.Time) (*PayCycle, error) {
+	var salary *RecurringPayment
+	for i := range income {
+		if income[i].Cadence == "annual" {
+			continue
+		}
+		if payer == "" || strings.Contains(strings.ToLower(income[i].Name), strings.ToLower(payer)) {
+			salary = &income[i]
+			break
+		}
+	}
+
+	if salary == nil {
+		if payer != "" {
+			return nil, fmt.Errorf("no recurring income from %q found", payer)
+		}
+		return nil, fmt.Errorf("no recurring income found. Paydays are detected after three payments from the same payer at a regular cadence")
+	}
+
+	cycle := &PayCycle{
+		Salary:        *salary,
+		PreviousStart: startOfDay(salary.PreviousDate.In(now.Location())),
+		Start:         startOfDay(salary.LastDate.In(now.Location())),
+		End:           startOfDay(salary.NextExpected.In(now.Location())),
+	}
+
+	// A late salary keeps the current cycle open until it arrives
+	if tomorrow := startOfDay(now).AddDate(0, 0, 1); cycle.End.Before(tomorrow) {
+		cycle.End = tomorrow
+	}
+	return cycle, nil
+}
+
+// resolvePayCycle detects the current pay cycle from an account's recent
+// transactions, using the salary payer from the config if set
+func resolvePayCycle(ctx context.Context, accessToken, accountID string, now time.Time) (*PayCycle, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Every page of the window is needed, as the latest salary is on the last page
+	transactions, err := fetchTransactions(ctx, accessToken, accountID, now.AddDate(0, -incomeHistoryMonths, 0), time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	return detectPayCycle(detectIncome(transactions.Transactions, now), config.SalaryPayer, now)
+}
+
+// validatePeriod checks a --period value
+func validatePeriod(period string) error {
+	if period != periodMonth && period != periodPayCycle {
+		return fmt.Errorf("invalid period %q: must be month or pay-cycle", period)
+	}
+	return nil
+}
+
+// startOfDay returns midnight at the start of t's day
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestDetectIncome(t *testing.T) {
+	var transactions []Transaction
+	for i, month := range []time.Month{time.March, time.April, time.May} {
+		paid := time.Date(2024, month, 25, 6, 0, 0, 0, time.UTC)
+		transactions = append(transactions,
+			Transaction{ID: fmt.Sprintf("tx_salary_%d", i), Created: paid.Format(time.RFC3339), Description: "ACME LTD SALARY", Amount: 320000 + int64(i)*5000, Currency: "GBP"},
+			// A side job paid by bank transfer with a different reference each time
+			Transaction{ID: fmt.Sprintf("tx_side_%d", i), Created: paid.AddDate(0, 0, -20).Format(time.RFC3339), Description: fmt.Sprintf("INVOICE %d", i), Amount: 40000, Currency: "GBP", Counterparty: &Counterparty{Name: "Side Gig Ltd"}},
+			Transaction{ID: fmt.Sprintf("tx_pot_%d", i), Created: paid.AddDate(0, 0, 1).Format(time.RFC3339), Description: "pot_mock", Amount: 5000, Currency: "GBP", Metadata: map[string]string{"pot_id": "pot_mock"}},
+		)
+	}
+	transactions = append(transactions, Transaction{ID: "tx_refund", Created: "2024-05-10T12:00:00Z", Description: "AMAZON REFUND", Amount: 1999, Currency: "GBP"})
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	income := detectIncome(transactions, now)
+	if len(income) != 2 {
+		t.Fatalf("Expected salary and side job income, got %+v", income)
+	}
+	if income[0].Name != "ACME LTD SALARY" || income[0].Cadence != "monthly" || income[0].LastAmount != 330000 {
+		t.Errorf("Expected the salary first, got %+v", income[0])
+	}
+	if income[1].Name != "Side Gig Ltd" {
+		t.Errorf("Expected transfers to be grouped by payer name, got %+v", income[1])
+	}
+
+	cycle, err := detectPayCycle(income, "", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cycle.Salary.Name != "ACME LTD SALARY" ||
+		!cycle.PreviousStart.Equal(time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC)) ||
+		!cycle.Start.Equal(time.Date(2024, 5, 25, 0, 0, 0, 0, time.UTC)) ||
+		!cycle.End.Equal(time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected pay cycle: %s to %s from %s", cycle.Start, cycle.End, cycle.Salary.Name)
+	}
+
+	cycle, err = detectPayCycle(income, "side gig", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cycle.Salary.Name != "Side Gig Ltd" {
+		t.Errorf("Expected the named payer to be used, got %s", cycle.Salary.Name)
+	}
+
+	if _, err := detectPayCycle(income, "Unknown Corp", now); err == nil {
+		t.Error("Expected an error for a payer with no recurring income")
+	}
+}
+
+func TestDetectPayCycleLateSalary(t *testing.T) {
+	income := []RecurringPayment{{
+		Name:         "ACME LTD SALARY",
+		Cadence:      "monthly",
+		LastDate:     time.Date(2024, 5, 25, 6, 0, 0, 0, time.UTC),
+		NextExpected: time.Date(2024, 6, 25, 6, 0, 0, 0, time.UTC),
+	}}
+
+	now := time.Date(2024, 6, 27, 9, 0, 0, 0, time.UTC)
+	cycle, err := detectPayCycle(income, "", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cycle.End.Equal(time.Date(2024, 6, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the cycle to stay open until the late salary arrives, got end %s", cycle.End)
+	}
+}
+
+func TestResolvePayCycleUsesLatestSalary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := useMockAPI(t)
+
+	// The mock's history is longer than a page, so the latest salary is only
+	// found by following every page
+	var latest time.Time
+	for _, tx := range mock.Transactions {
+		if tx.AccountID == monzotest.PersonalAccountID && tx.Description == "ACME LTD SALARY" {
+			latest, _ = time.Parse(time.RFC3339Nano, tx.Created)
+		}
+	}
+
+	now := time.Now()
+	cycle, err := resolvePayCycle(context.Background(), monzotest.AccessToken, monzotest.PersonalAccountID, now)
+	if err != nil {
+		t.Fatalf("Failed to resolve pay cycle: %v", err)
+	}
+	if !cycle.Start.Equal(startOfDay(latest.In(now.Location()))) {
+		t.Errorf("Expected the pay cycle to start on the latest payday %s, got %s", latest.Format(dateLayout), cycle.Start.Format(dateLayout))
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	potsAccountID string
+	potsOutput    string
+)
+
+// Pot represents a Monzo savings pot
+type Pot struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Style            string `json:"style"`
+	Balance          int64  `json:"balance"`
+	Currency         string `json:"currency"`
+	Created          string `json:"created"`
+	Updated          string `json:"updated"`
+	Deleted          bool   `json:"deleted"`
+	CurrentAccountID string `json:"current_account_id,omitempty"`
+}
+
+// PotsResponse represents the response from the pots endpoint
+type PotsResponse struct {
+	Pots []Pot `json:"pots"`
+}
+
+var potsCmd = &cobra.Command{
+	Use:   "pots",
+	Short: "List pots for an account",
+	Long: `List the pots belonging to a Monzo account with their balances.
+
+Deleted pots are not shown.
+
+You must be logged in before using this command. Use 'go-monzo login' first.
+You can obtain your account ID using the 'go-monzo accounts' command.`,
+	RunE: runPots,
+}
+
+func init() {
+	rootCmd.AddCommand(potsCmd)
+
+	potsCmd.Flags().StringVar(&potsAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	potsCmd.Flags().StringVarP(&potsOutput, "output", "o", "table", "Output format: table or json")
+
+	_ = potsCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = potsCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+}
+
+func runPots(cmd *cobra.Command, args []string) error {
+	if potsAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(potsOutput); err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	pots, err := fetchPots(cmd.Context(), token.AccessToken, potsAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pots: %w", err)
+	}
+
+	open := openPots(pots.Pots)
+
+	if potsOutput == "json" {
+		output, err := json.MarshalIndent(PotsResponse{Pots: open}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal pots: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tBALANCE")
+	for _, pot := range open {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pot.ID, pot.Name, NewMoney(pot.Balance, pot.Currency).String())
+	}
+	return w.Flush()
+}
+
+// openPots returns the pots that haven't been deleted
+func openPots(pots []Pot) []Pot {
+	open := make([]Pot, 0, len(pots))
+	for _, pot := range pots {
+		if !pot.Deleted {
+			open = append(open, pot)
+		}
+	}
+	return open
+}
+
+func fetchPots(ctx context.Context, accessToken, accountID string) (*PotsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	reqURL := fmt.Sprintf("%s/pots?current_account_id=%s", apiBaseURL, url.QueryEscape(accountID))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pots PotsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pots); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &pots, nil
+}
+
+// depositIntoPot moves money from an account into a pot. Requests with the
+// same dedupeID are only applied once, so retries are safe.
+func depositIntoPot(ctx context.Context, accessToken, potID, sourceAccountID string, amount int64, dedupeID string) (*Pot, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	data := url.Values{}
+	data.Set("source_account_id", sourceAccountID)
+	data.Set("amount", strconv.FormatInt(amount, 10))
+	data.Set("dedupe_id", dedupeID)
+
+	reqURL := fmt.Sprintf("%s/pots/%s/deposit", apiBaseURL, url.PathEscape(potID))
+	req, err := http.NewRequestWithContext(ctx, "PUT", reqURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var pot Pot
+	if err := json.NewDecoder(resp.Body).Decode(&pot); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &pot, nil
+}
+
+// newDedupeID returns a random ID for a money movement request
+func newDedupeID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
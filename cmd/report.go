@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	reportAccountID string
+	reportFrom      string
+	reportTo        string
+	reportGroupBy   string
+	reportOutput    string
+	reportPeriod    string
+)
+
+// SpendingReport represents a spending summary for a period, compared with
+// the same part of the previous month or pay cycle
+type SpendingReport struct {
+	AccountID     string          `json:"account_id"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	PreviousFrom  time.Time       `json:"previous_from"`
+	PreviousTo    time.Time       `json:"previous_to"`
+	GroupBy       string          `json:"group_by"`
+	Currency      string          `json:"currency"`
+	Total         int64           `json:"total"`
+	Count         int             `json:"count"`
+	PreviousTotal int64           `json:"previous_total"`
+	Groups        []SpendingGroup `json:"groups"`
+}
+
+// SpendingGroup represents the spending for a single group in a report
+type SpendingGroup struct {
+	Key           string `json:"key"`
+	Total         int64  `json:"total"`
+	Count         int    `json:"count"`
+	PreviousTotal *int64 `json:"previous_total,omitempty"`
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Analyse account activity",
+	Long: `Analyse account activity using transaction history from the Monzo API.
+
+Reports cover the current calendar month by default. With --period pay-cycle
+they start from your last payday instead; see 'go-monzo payday' for how
+paydays are detected.
+
+You must be logged in before using these commands. Use 'go-monzo login' first.`,
+}
+
+var reportSpendingCmd = &cobra.Command{
+	Use:   "spending",
+	Short: "Summarise spending for a period",
+	Long: `Summarise outgoing spending for a period, grouped by category, merchant,
+day, week or month, and compare it with the same part of the previous month,
+or of the previous pay cycle with --period pay-cycle. A report that spans
+several months is compared with the same number of months before it.
+
+Only debits that Monzo includes in spending are counted. Declined transactions
+and transfers to and from pots are excluded.
+
+Dates use the YYYY-MM-DD format. --from defaults to the start of the current
+month, or the last payday with --period pay-cycle, and --to defaults to today;
+both days are included in the report.`,
+	RunE: runReportSpending,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportSpendingCmd)
+
+	reportCmd.PersistentFlags().StringVar(&reportAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	reportCmd.PersistentFlags().StringVar(&reportFrom, "from", "", "Start date of the report (YYYY-MM-DD)")
+	reportCmd.PersistentFlags().StringVar(&reportTo, "to", "", "End date of the report, inclusive (YYYY-MM-DD)")
+	reportCmd.PersistentFlags().StringVarP(&reportOutput, "output", "o", "table", "Output format: table or json")
+	reportCmd.PersistentFlags().StringVar(&reportPeriod, "period", periodMonth, "Default period when --from isn't set: month or pay-cycle")
+
+	reportSpendingCmd.Flags().StringVar(&reportGroupBy, "group-by", "category", "Group spending by category, merchant, day, week or month")
+
+	_ = reportCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = reportCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+	_ = reportCmd.RegisterFlagCompletionFunc("period", fixedCompletions(periodMonth, periodPayCycle))
+	_ = reportSpendingCmd.RegisterFlagCompletionFunc("group-by", fixedCompletions("category", "merchant", "day", "week", "month"))
+}
+
+func runReportSpending(cmd *cobra.Command, args []string) error {
+	if reportAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if _, err := spendingGroupKey(Transaction{}, reportGroupBy); err != nil {
+		return err
+	}
+
+	if err := validateOutputFormat(reportOutput); err != nil {
+		return err
+	}
+
+	if err := validateReportPeriod(); err != nil {
+		return err
+	}
+
+	from, to, err := parseDateRange(reportFrom, reportTo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	from, cycle, err := applyReportPeriod(cmd.Context(), token.AccessToken, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Fetch both the report period and the previous period together
+	previousFrom, previousTo := previousReportPeriod(from, to, cycle)
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, reportAccountID, previousFrom, to)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	report, err := buildSpendingReport(transactions.Transactions, from, to, previousFrom, previousTo, reportGroupBy)
+	if err != nil {
+		return err
+	}
+	report.AccountID = reportAccountID
+
+	if reportOutput == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	printSpendingReport(report)
+	return nil
+}
+
+// buildSpendingReport totals spending between from and to, and between
+// previousFrom and previousTo, grouped by groupBy
+func buildSpendingReport(transactions []Transaction, from, to, previousFrom, previousTo time.Time, groupBy string) (*SpendingReport, error) {
+	report := &SpendingReport{
+		From:         from,
+		To:           to,
+		PreviousFrom: previousFrom,
+		PreviousTo:   previousTo,
+		GroupBy:      groupBy,
+		Groups:       []SpendingGroup{},
+	}
+
+	groups := make(map[string]*SpendingGroup)
+	previous := make(map[string]int64)
+
+	for _, tx := range transactions {
+		if !tx.IsSpending() {
+			continue
+		}
+
+		created := tx.CreatedTime()
+		key, err := spendingGroupKey(tx, groupBy)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !created.Before(from) && created.Before(to):
+			if report.Currency == "" {
+				report.Currency = tx.Currency
+			}
+			group, ok := groups[key]
+			if !ok {
+				group = &SpendingGroup{Key: key}
+				groups[key] = group
+			}
+			group.Total += -tx.Amount
+			group.Count++
+			report.Total += -tx.Amount
+			report.Count++
+		case !created.Before(previousFrom) && created.Before(previousTo):
+			previous[key] += -tx.Amount
+			report.PreviousTotal += -tx.Amount
+		}
+	}
+
+	// Per-group comparisons only make sense when groups repeat across periods
+	compareGroups := groupBy == "category" || groupBy == "merchant"
+
+	for key, group := range groups {
+		if compareGroups {
+			previousTotal := previous[key]
+			group.PreviousTotal = &previousTotal
+		}
+		report.Groups = append(report.Groups, *group)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if compareGroups && report.Groups[i].Total != report.Groups[j].Total {
+			return report.Groups[i].Total > report.Groups[j].Total
+		}
+		return report.Groups[i].Key < report.Groups[j].Key
+	})
+
+	return report, nil
+}
+
+// spendingGroupKey returns the key a transaction is grouped under. Dates are
+// local, like the report period.
+func spendingGroupKey(tx Transaction, groupBy string) (string, error) {
+	created := tx.CreatedTime().In(time.Local)
+	switch groupBy {
+	case "category":
+		return tx.Category, nil
+	case "merchant":
+		return tx.MerchantName(), nil
+	case "day":
+		return created.Format(dateLayout), nil
+	case "week":
+		year, week := created.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	case "month":
+		return created.Format("2006-01"), nil
+	default:
+		return "", fmt.Errorf("invalid --group-by value %q: must be category, merchant, day, week or month", groupBy)
+	}
+}
+
+func printSpendingReport(report *SpendingReport) {
+	fmt.Printf("Spending from %s to %s\n\n", report.From.Format(dateLayout), report.To.AddDate(0, 0, -1).Format(dateLayout))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tSPENT\tCOUNT\tPREVIOUS\tCHANGE\n", strings.ToUpper(report.GroupBy))
+	for _, group := range report.Groups {
+		previous, change := "-", "-"
+		if group.PreviousTotal != nil {
+			previous = NewMoney(*group.PreviousTotal, report.Currency).String()
+			change = formatChange(group.Total, *group.PreviousTotal)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", group.Key, NewMoney(group.Total, report.Currency).String(), group.Count, previous, change)
+	}
+	fmt.Fprintf(w, "TOTAL\t%s\t%d\t%s\t%s\n",
+		NewMoney(report.Total, report.Currency).String(),
+		report.Count,
+		NewMoney(report.PreviousTotal, report.Currency).String(),
+		formatChange(report.Total, report.PreviousTotal))
+	_ = w.Flush()
+}
+
+// formatChange formats the percentage change from previous to current
+func formatChange(current, previous int64) string {
+	if previous == 0 {
+		if current == 0 {
+			return "0.0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(current-previous)/float64(previous)*100)
+}
+
+// parseDateRange parses --from and --to dates. The returned range is
+// half-open: it starts at midnight on from and ends at midnight after to.
+func parseDateRange(fromValue, toValue string, now time.Time) (time.Time, time.Time, error) {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+
+	if fromValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromValue, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from date %q: expected YYYY-MM-DD", fromValue)
+		}
+		from = parsed
+	}
+
+	if toValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toValue, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to date %q: expected YYYY-MM-DD", toValue)
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--from date must not be after --to date")
+	}
+
+	return from, to, nil
+}
+
+// validateReportPeriod checks --period and that it isn't combined with --from
+func validateReportPeriod() error {
+	if err := validatePeriod(reportPeriod); err != nil {
+		return err
+	}
+	if reportPeriod == periodPayCycle && reportFrom != "" {
+		return fmt.Errorf("--from can't be used with --period pay-cycle")
+	}
+	return nil
+}
+
+// applyReportPeriod moves the start of a report to the last payday when
+// --period is pay-cycle, returning the pay cycle. The cycle is nil for
+// calendar month periods.
+func applyReportPeriod(ctx context.Context, accessToken string, from, to time.Time) (time.Time, *PayCycle, error) {
+	if reportPeriod != periodPayCycle {
+		return from, nil, nil
+	}
+
+	cycle, err := resolvePayCycle(ctx, accessToken, reportAccountID, time.Now())
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if !cycle.Start.Before(to) {
+		return time.Time{}, nil, fmt.Errorf("--to date must not be before the last payday, %s", cycle.Start.Format(dateLayout))
+	}
+	return cycle.Start, cycle, nil
+}
+
+// previousReportPeriod returns the period a report is compared with: the same
+// days of the previous pay cycle if cycle is set, or otherwise the same dates
+// in the months before, going back as many calendar months as the report
+// spans. The previous period never overlaps the report.
+func previousReportPeriod(from, to time.Time, cycle *PayCycle) (time.Time, time.Time) {
+	var previousFrom, previousTo time.Time
+	if cycle != nil {
+		days := int(math.Round(to.Sub(from).Hours() / 24))
+		previousFrom = cycle.PreviousStart
+		previousTo = previousFrom.AddDate(0, 0, days)
+	} else {
+		last := to.AddDate(0, 0, -1)
+		months := (last.Year()-from.Year())*12 + int(last.Month()-from.Month()) + 1
+		previousFrom = from.AddDate(0, -months, 0)
+		previousTo = to.AddDate(0, -months, 0)
+	}
+
+	if previousTo.After(from) {
+		previousTo = from
+	}
+	return previousFrom, previousTo
+}
+
+func validateOutputFormat(format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid output format %q: must be table or json", format)
+	}
+	return nil
+}
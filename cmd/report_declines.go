@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const declinesStateFile = "declines.json"
+
+// declinesOverlap is how far before the last run --since-last-run looks, so
+// that declines which reach the API late aren't missed
+const declinesOverlap = 24 * time.Hour
+
+var declinesSinceLastRun bool
+
+// declineReasons describes the decline reasons returned by the API
+var declineReasons = map[string]string{
+	"INSUFFICIENT_FUNDS":       "insufficient funds",
+	"CARD_INACTIVE":            "card inactive",
+	"CARD_BLOCKED":             "card blocked",
+	"CARD_CLOSED":              "card closed",
+	"INVALID_CVC":              "incorrect CVC",
+	"INVALID_EXPIRY_DATE":      "incorrect expiry date",
+	"INVALID_PIN":              "incorrect PIN",
+	"PIN_RETRY_COUNT_EXCEEDED": "too many PIN attempts",
+	"STRONG_CUSTOMER_AUTHENTICATION_REQUIRED": "authentication required",
+	"AUTHENTICATION_REJECTED_BY_CARDHOLDER":   "rejected in the app",
+	"OTHER":                                   "other",
+}
+
+// DeclinesReport represents the declined transactions in a period
+type DeclinesReport struct {
+	AccountID    string          `json:"account_id"`
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	SinceLastRun bool            `json:"since_last_run"`
+	Count        int             `json:"count"`
+	Totals       []CurrencyTotal `json:"totals"`
+	Declines     []DeclinedTx    `json:"declines"`
+	ByReason     []DeclinesGroup `json:"by_reason"`
+	ByMerchant   []DeclinesGroup `json:"by_merchant"`
+	ByDay        []DeclinesGroup `json:"by_day"`
+}
+
+// DeclinedTx represents a declined transaction. Amounts are positive and
+// times are local.
+type DeclinedTx struct {
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	Merchant string    `json:"merchant"`
+	Amount   int64     `json:"amount"`
+	Currency string    `json:"currency"`
+	Reason   string    `json:"reason"`
+}
+
+// DeclinesGroup represents the declines in one currency for a reason, merchant
+// or day
+type DeclinesGroup struct {
+	Key      string `json:"key"`
+	Currency string `json:"currency"`
+	Count    int    `json:"count"`
+	Amount   int64  `json:"amount"`
+}
+
+// DeclinesCheckpoint records the declines already reported for an account
+type DeclinesCheckpoint struct {
+	LastRun string   `json:"last_run"`
+	Seen    []string `json:"seen"`
+}
+
+// DeclinesState represents the checkpoints stored in ~/.go-monzo/declines.json
+type DeclinesState struct {
+	Accounts map[string]*DeclinesCheckpoint `json:"accounts"`
+}
+
+var reportDeclinesCmd = &cobra.Command{
+	Use:   "declines",
+	Short: "Summarise declined transactions",
+	Long: `List declined transactions for a period, grouped by the reason they were
+declined, by merchant and by day.
+
+With --since-last-run only declines that haven't been reported by an earlier
+--since-last-run are shown, and the command exits with a non-zero status if
+there are any, so it can be run from cron to alert on new declines. The
+declines already reported are recorded in ~/.go-monzo/declines.json. The first
+run reports the declines for the usual period.
+
+Dates use the YYYY-MM-DD format. --from defaults to the start of the current
+month, or the last payday with --period pay-cycle, and --to defaults to today;
+both days are included in the report.`,
+	RunE: runReportDeclines,
+}
+
+func init() {
+	reportCmd.AddCommand(reportDeclinesCmd)
+
+	reportDeclinesCmd.Flags().BoolVar(&declinesSinceLastRun, "since-last-run", false, "Only report declines not reported by the last run, and exit non-zero if there are any")
+}
+
+func runReportDeclines(cmd *cobra.Command, args []string) error {
+	if reportAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(reportOutput); err != nil {
+		return err
+	}
+
+	if err := validateReportPeriod(); err != nil {
+		return err
+	}
+
+	if declinesSinceLastRun && (reportFrom != "" || reportTo != "") {
+		return fmt.Errorf("--from and --to can't be used with --since-last-run")
+	}
+
+	now := time.Now()
+	from, to, err := parseDateRange(reportFrom, reportTo, now)
+	if err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	var state *DeclinesState
+	var checkpoint *DeclinesCheckpoint
+	if declinesSinceLastRun {
+		state, err = loadDeclinesState()
+		if err != nil {
+			return err
+		}
+		checkpoint = state.Accounts[reportAccountID]
+	}
+
+	if checkpoint != nil {
+		lastRun, err := time.Parse(time.RFC3339Nano, checkpoint.LastRun)
+		if err != nil {
+			return fmt.Errorf("invalid last run %q in %s: %w", checkpoint.LastRun, declinesStateFile, err)
+		}
+		from = lastRun.Add(-declinesOverlap)
+	} else {
+		from, _, err = applyReportPeriod(cmd.Context(), token.AccessToken, from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, reportAccountID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	var seen []string
+	if checkpoint != nil {
+		seen = checkpoint.Seen
+	}
+	report := buildDeclinesReport(transactions.Transactions, seen)
+	report.AccountID = reportAccountID
+	report.From = from
+	report.To = to
+	report.SinceLastRun = declinesSinceLastRun
+
+	if declinesSinceLastRun {
+		// Only declines that could be fetched again next time are remembered
+		checkpoint = &DeclinesCheckpoint{LastRun: now.UTC().Format(time.RFC3339Nano), Seen: []string{}}
+		for _, tx := range transactions.Transactions {
+			if tx.IsDeclined() {
+				checkpoint.Seen = append(checkpoint.Seen, tx.ID)
+			}
+		}
+		if state.Accounts == nil {
+			state.Accounts = make(map[string]*DeclinesCheckpoint)
+		}
+		state.Accounts[reportAccountID] = checkpoint
+		if err := saveStateFile(declinesStateFile, state); err != nil {
+			return fmt.Errorf("failed to save declines checkpoint: %w", err)
+		}
+	}
+
+	if reportOutput == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		printDeclinesReport(report)
+	}
+
+	if declinesSinceLastRun && report.Count > 0 {
+		// The error is the result rather than a usage problem
+		cmd.SilenceUsage = true
+		return fmt.Errorf("new declined transactions: %d", report.Count)
+	}
+	return nil
+}
+
+// loadDeclinesState loads the checkpoints from ~/.go-monzo/declines.json
+func loadDeclinesState() (*DeclinesState, error) {
+	var state DeclinesState
+	if err := loadStateFile(declinesStateFile, &state); err != nil {
+		return nil, fmt.Errorf("failed to load declines checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// buildDeclinesReport lists and groups the declined transactions, leaving out
+// those whose IDs are in seen
+func buildDeclinesReport(transactions []Transaction, seen []string) *DeclinesReport {
+	skip := make(map[string]bool, len(seen))
+	for _, id := range seen {
+		skip[id] = true
+	}
+
+	report := &DeclinesReport{Declines: []DeclinedTx{}}
+	totals := make(map[string]*CurrencyTotal)
+	byReason := make(map[string]*DeclinesGroup)
+	byMerchant := make(map[string]*DeclinesGroup)
+	byDay := make(map[string]*DeclinesGroup)
+
+	add := func(groups map[string]*DeclinesGroup, key string, decline DeclinedTx) {
+		// Amounts in different currencies are kept apart
+		id := key + "\x00" + decline.Currency
+		group, ok := groups[id]
+		if !ok {
+			group = &DeclinesGroup{Key: key, Currency: decline.Currency}
+			groups[id] = group
+		}
+		group.Count++
+		group.Amount += decline.Amount
+	}
+
+	for _, tx := range transactions {
+		if !tx.IsDeclined() || skip[tx.ID] {
+			continue
+		}
+
+		decline := DeclinedTx{
+			ID:       tx.ID,
+			Created:  tx.CreatedTime().Local(),
+			Merchant: tx.MerchantName(),
+			Amount:   abs64(tx.Amount),
+			Currency: tx.Currency,
+			Reason:   describeDeclineReason(tx.DeclineReason),
+		}
+		report.Declines = append(report.Declines, decline)
+		report.Count++
+		addCurrencyTotal(totals, decline.Currency, decline.Amount)
+
+		add(byReason, decline.Reason, decline)
+		add(byMerchant, decline.Merchant, decline)
+		add(byDay, decline.Created.Format(dateLayout), decline)
+	}
+
+	sort.SliceStable(report.Declines, func(i, j int) bool {
+		return report.Declines[i].Created.Before(report.Declines[j].Created)
+	})
+
+	report.Totals = sortedCurrencyTotals(totals)
+	report.ByReason = sortedDeclinesGroups(byReason, false)
+	report.ByMerchant = sortedDeclinesGroups(byMerchant, false)
+	report.ByDay = sortedDeclinesGroups(byDay, true)
+	return report
+}
+
+// sortedDeclinesGroups orders groups by key, or by count with the most
+// declines first, then by currency
+func sortedDeclinesGroups(groups map[string]*DeclinesGroup, byKey bool) []DeclinesGroup {
+	sorted := make([]DeclinesGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if !byKey && sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		if sorted[i].Key != sorted[j].Key {
+			return sorted[i].Key < sorted[j].Key
+		}
+		return sorted[i].Currency < sorted[j].Currency
+	})
+	return sorted
+}
+
+// describeDeclineReason returns a readable description of a decline reason
+func describeDeclineReason(reason string) string {
+	if description, ok := declineReasons[reason]; ok {
+		return description
+	}
+	return strings.ToLower(strings.ReplaceAll(reason, "_", " "))
+}
+
+func printDeclinesReport(report *DeclinesReport) {
+	if report.Count == 0 {
+		if report.SinceLastRun {
+			fmt.Println("No new declined transactions")
+		} else {
+			fmt.Printf("No declined transactions from %s to %s\n", report.From.Format(dateLayout), report.To.AddDate(0, 0, -1).Format(dateLayout))
+		}
+		return
+	}
+
+	if report.SinceLastRun {
+		fmt.Printf("New declined transactions: %d\n\n", report.Count)
+	} else {
+		fmt.Printf("Declined transactions from %s to %s\n\n", report.From.Format(dateLayout), report.To.AddDate(0, 0, -1).Format(dateLayout))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tMERCHANT\tAMOUNT\tREASON")
+	for _, decline := range report.Declines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", decline.Created.Format("2006-01-02 15:04"), decline.Merchant, NewMoney(decline.Amount, decline.Currency).String(), decline.Reason)
+	}
+	for _, total := range report.Totals {
+		fmt.Fprintf(w, "TOTAL\t\t%s\t\n", NewMoney(total.Amount, total.Currency).String())
+	}
+	_ = w.Flush()
+
+	for _, section := range []struct {
+		title  string
+		groups []DeclinesGroup
+	}{
+		{"REASON", report.ByReason},
+		{"MERCHANT", report.ByMerchant},
+		{"DAY", report.ByDay},
+	} {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tCOUNT\tAMOUNT\n", section.title)
+		for _, group := range section.groups {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", group.Key, group.Count, NewMoney(group.Amount, group.Currency).String())
+		}
+		_ = w.Flush()
+	}
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fxTripGap   int
+	fxThreshold float64
+)
+
+// FXReport represents foreign currency spending for a period
+type FXReport struct {
+	AccountID  string              `json:"account_id"`
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Currencies []FXCurrencySummary `json:"currencies"`
+	Trips      []FXTrip            `json:"trips"`
+}
+
+// FXCurrencySummary represents spending in a single local currency
+type FXCurrencySummary struct {
+	LocalCurrency string          `json:"local_currency"`
+	Currency      string          `json:"currency"`
+	Count         int             `json:"count"`
+	LocalTotal    int64           `json:"local_total"`
+	Total         int64           `json:"total"`
+	MedianRate    float64         `json:"median_rate"`
+	Transactions  []FXTransaction `json:"transactions"`
+}
+
+// FXTransaction represents a foreign currency transaction and the effective
+// exchange rate paid, in local currency units per account currency unit
+type FXTransaction struct {
+	ID            string    `json:"id"`
+	Created       time.Time `json:"created"`
+	Merchant      string    `json:"merchant"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	LocalAmount   int64     `json:"local_amount"`
+	LocalCurrency string    `json:"local_currency"`
+	Rate          float64   `json:"rate"`
+	Deviation     float64   `json:"deviation"` // Relative difference from the median rate
+	Outlier       bool      `json:"outlier"`
+}
+
+// FXTrip represents a run of spending in one local currency without long gaps
+type FXTrip struct {
+	LocalCurrency string    `json:"local_currency"`
+	Currency      string    `json:"currency"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Count         int       `json:"count"`
+	LocalTotal    int64     `json:"local_total"`
+	Total         int64     `json:"total"`
+}
+
+var reportFXCmd = &cobra.Command{
+	Use:   "fx",
+	Short: "Summarise foreign currency spending",
+	Long: `Summarise spending made in foreign currencies, using the local amount and
+currency recorded on each transaction.
+
+Spending is grouped by local currency with the effective exchange rate paid on
+each transaction. Runs of spending in the same currency are grouped into trips,
+and transactions whose rate deviates from the median for that currency by more
+than --threshold are flagged.
+
+Dates use the YYYY-MM-DD format. --from defaults to the start of the current
+month, or the last payday with --period pay-cycle, and --to defaults to today;
+both days are included in the report.`,
+	RunE: runReportFX,
+}
+
+func init() {
+	reportCmd.AddCommand(reportFXCmd)
+
+	reportFXCmd.Flags().IntVar(&fxTripGap, "trip-gap", 3, "Days without spending in a currency that end a trip")
+	reportFXCmd.Flags().Float64Var(&fxThreshold, "threshold", 0.05, "Flag rates deviating from the median by more than this fraction")
+}
+
+func runReportFX(cmd *cobra.Command, args []string) error {
+	if reportAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(reportOutput); err != nil {
+		return err
+	}
+
+	if err := validateReportPeriod(); err != nil {
+		return err
+	}
+
+	from, to, err := parseDateRange(reportFrom, reportTo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	from, _, err = applyReportPeriod(cmd.Context(), token.AccessToken, from, to)
+	if err != nil {
+		return err
+	}
+
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, reportAccountID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	report := buildFXReport(transactions.Transactions, time.Duration(fxTripGap)*24*time.Hour, fxThreshold)
+	report.AccountID = reportAccountID
+	report.From = from
+	report.To = to
+
+	if reportOutput == "json" {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	printFXReport(report)
+	return nil
+}
+
+// buildFXReport groups foreign currency spending by local currency and trip
+func buildFXReport(transactions []Transaction, tripGap time.Duration, threshold float64) *FXReport {
+	byCurrency := make(map[string][]FXTransaction)
+
+	for _, tx := range transactions {
+		if tx.Amount >= 0 || tx.IsDeclined() || tx.LocalAmount == 0 || tx.LocalCurrency == "" || tx.LocalCurrency == tx.Currency {
+			continue
+		}
+
+		byCurrency[tx.LocalCurrency] = append(byCurrency[tx.LocalCurrency], FXTransaction{
+			ID:            tx.ID,
+			Created:       tx.CreatedTime(),
+			Merchant:      tx.MerchantName(),
+			Amount:        tx.Amount,
+			Currency:      tx.Currency,
+			LocalAmount:   tx.LocalAmount,
+			LocalCurrency: tx.LocalCurrency,
+			Rate:          exchangeRate(tx.Amount, tx.Currency, tx.LocalAmount, tx.LocalCurrency),
+		})
+	}
+
+	report := &FXReport{Currencies: []FXCurrencySummary{}, Trips: []FXTrip{}}
+
+	for localCurrency, txs := range byCurrency {
+		sort.Slice(txs, func(i, j int) bool { return txs[i].Created.Before(txs[j].Created) })
+
+		rates := make([]float64, len(txs))
+		for i, tx := range txs {
+			rates[i] = tx.Rate
+		}
+
+		summary := FXCurrencySummary{
+			LocalCurrency: localCurrency,
+			Currency:      txs[0].Currency,
+			Count:         len(txs),
+			MedianRate:    median(rates),
+		}
+
+		var trip *FXTrip
+		for i := range txs {
+			tx := &txs[i]
+			if summary.MedianRate > 0 {
+				tx.Deviation = (tx.Rate - summary.MedianRate) / summary.MedianRate
+				tx.Outlier = math.Abs(tx.Deviation) > threshold
+			}
+			summary.LocalTotal += -tx.LocalAmount
+			summary.Total += -tx.Amount
+
+			if trip == nil || tx.Created.Sub(trip.To) > tripGap {
+				if trip != nil {
+					report.Trips = append(report.Trips, *trip)
+				}
+				trip = &FXTrip{LocalCurrency: localCurrency, Currency: tx.Currency, From: tx.Created}
+			}
+			trip.To = tx.Created
+			trip.Count++
+			trip.LocalTotal += -tx.LocalAmount
+			trip.Total += -tx.Amount
+		}
+		report.Trips = append(report.Trips, *trip)
+
+		summary.Transactions = txs
+		report.Currencies = append(report.Currencies, summary)
+	}
+
+	sort.Slice(report.Currencies, func(i, j int) bool {
+		return report.Currencies[i].Total > report.Currencies[j].Total
+	})
+	sort.Slice(report.Trips, func(i, j int) bool {
+		return report.Trips[i].From.Before(report.Trips[j].From)
+	})
+
+	return report
+}
+
+// exchangeRate returns the local currency units received per unit of the
+// account currency
+func exchangeRate(amount int64, currency string, localAmount int64, localCurrency string) float64 {
+	if amount == 0 {
+		return 0
+	}
+	major := NewMoney(amount, currency).Major()
+	localMajor := NewMoney(localAmount, localCurrency).Major()
+	return math.Abs(localMajor / major)
+}
+
+func printFXReport(report *FXReport) {
+	fmt.Printf("Foreign currency spending from %s to %s\n", report.From.Format(dateLayout), report.To.AddDate(0, 0, -1).Format(dateLayout))
+
+	if len(report.Currencies) == 0 {
+		fmt.Println("\nNo foreign currency spending found.")
+		return
+	}
+
+	for _, summary := range report.Currencies {
+		fmt.Printf("\n%s: %d transactions, %s for %s, median rate %.4f\n\n",
+			summary.LocalCurrency,
+			summary.Count,
+			NewMoney(summary.LocalTotal, summary.LocalCurrency).String(),
+			NewMoney(summary.Total, summary.Currency).String(),
+			summary.MedianRate)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tMERCHANT\tLOCAL\tAMOUNT\tRATE\tDEVIATION\t")
+		for _, tx := range summary.Transactions {
+			flag := ""
+			if tx.Outlier {
+				flag = "!"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.4f\t%+.1f%%\t%s\n",
+				tx.Created.Format(dateLayout),
+				tx.Merchant,
+				NewMoney(-tx.LocalAmount, tx.LocalCurrency).String(),
+				NewMoney(-tx.Amount, tx.Currency).String(),
+				tx.Rate,
+				tx.Deviation*100,
+				flag)
+		}
+		_ = w.Flush()
+	}
+
+	fmt.Println("\nTrips")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tCURRENCY\tCOUNT\tLOCAL\tAMOUNT")
+	for _, trip := range report.Trips {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			trip.From.Format(dateLayout),
+			trip.To.Format(dateLayout),
+			trip.LocalCurrency,
+			trip.Count,
+			NewMoney(trip.LocalTotal, trip.LocalCurrency).String(),
+			NewMoney(trip.Total, trip.Currency).String())
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildSpendingReport(t *testing.T) {
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-02-03T12:00:00Z", Amount: -1200, Currency: "GBP", Category: "groceries", IncludeInSpending: true},
+		{ID: "tx_2", Created: "2024-02-10T12:00:00Z", Amount: -800, Currency: "GBP", Category: "groceries", IncludeInSpending: true},
+		{ID: "tx_3", Created: "2024-02-11T12:00:00Z", Amount: -2500, Currency: "GBP", Category: "eating_out", IncludeInSpending: true},
+		// Previous period
+		{ID: "tx_4", Created: "2024-01-15T12:00:00Z", Amount: -1000, Currency: "GBP", Category: "groceries", IncludeInSpending: true},
+		// Excluded: declined, pot transfer, income and not included in spending
+		{ID: "tx_5", Created: "2024-02-12T12:00:00Z", Amount: -5000, Currency: "GBP", Category: "shopping", IncludeInSpending: true, DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_6", Created: "2024-02-13T12:00:00Z", Amount: -10000, Currency: "GBP", Category: "savings", IncludeInSpending: true, Metadata: map[string]string{"pot_id": "pot_123"}},
+		{ID: "tx_7", Created: "2024-02-14T12:00:00Z", Amount: 200000, Currency: "GBP", Category: "income", IncludeInSpending: true},
+		{ID: "tx_8", Created: "2024-02-15T12:00:00Z", Amount: -700, Currency: "GBP", Category: "transfers", IncludeInSpending: false},
+	}
+
+	report, err := buildSpendingReport(transactions, from, to, from.AddDate(0, -1, 0), from, "category")
+	if err != nil {
+		t.Fatalf("Failed to build report: %v", err)
+	}
+
+	if report.Total != 4500 {
+		t.Errorf("Expected total 4500, got %d", report.Total)
+	}
+
+	if report.PreviousTotal != 1000 {
+		t.Errorf("Expected previous total 1000, got %d", report.PreviousTotal)
+	}
+
+	if len(report.Groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(report.Groups))
+	}
+
+	// Groups are sorted by total, largest first
+	if report.Groups[0].Key != "eating_out" || report.Groups[0].Total != 2500 {
+		t.Errorf("Expected eating_out 2500 first, got %s %d", report.Groups[0].Key, report.Groups[0].Total)
+	}
+
+	groceries := report.Groups[1]
+	if groceries.Count != 2 || groceries.PreviousTotal == nil || *groceries.PreviousTotal != 1000 {
+		t.Errorf("Unexpected groceries group: %+v", groceries)
+	}
+}
+
+func TestPreviousReportPeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	cycle := &PayCycle{PreviousStart: date(2024, 2, 23), Start: date(2024, 3, 25), End: date(2024, 4, 25)}
+
+	tests := []struct {
+		name         string
+		from, to     time.Time
+		cycle        *PayCycle
+		previousFrom time.Time
+		previousTo   time.Time
+	}{
+		{"month to date", date(2024, 3, 1), date(2024, 3, 16), nil, date(2024, 2, 1), date(2024, 2, 16)},
+		{"whole month", date(2024, 3, 1), date(2024, 4, 1), nil, date(2024, 2, 1), date(2024, 3, 1)},
+		{"several months", date(2024, 5, 1), date(2024, 9, 1), nil, date(2024, 1, 1), date(2024, 5, 1)},
+		{"longer than the previous month", date(2024, 3, 1), date(2024, 3, 31), nil, date(2024, 2, 1), date(2024, 3, 1)},
+		{"pay cycle", date(2024, 3, 25), date(2024, 4, 2), cycle, date(2024, 2, 23), date(2024, 3, 2)},
+		{"pay cycle longer than the previous one", date(2024, 3, 25), date(2024, 4, 25), cycle, date(2024, 2, 23), date(2024, 3, 25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousFrom, previousTo := previousReportPeriod(tt.from, tt.to, tt.cycle)
+			if !previousFrom.Equal(tt.previousFrom) || !previousTo.Equal(tt.previousTo) {
+				t.Errorf("Expected %s to %s, got %s to %s",
+					tt.previousFrom.Format(dateLayout), tt.previousTo.Format(dateLayout),
+					previousFrom.Format(dateLayout), previousTo.Format(dateLayout))
+			}
+		})
+	}
+}
+
+func TestSpendingGroupKeyLocalTime(t *testing.T) {
+	// British Summer Time, so 23:30 UTC is just after local midnight
+	originalLocal := time.Local
+	time.Local = time.FixedZone("BST", 60*60)
+	t.Cleanup(func() { time.Local = originalLocal })
+
+	tx := Transaction{ID: "tx_1", Created: "2024-06-30T23:30:00Z", Amount: -500, Currency: "GBP", IncludeInSpending: true}
+
+	tests := []struct {
+		groupBy  string
+		expected string
+	}{
+		{"day", "2024-07-01"},
+		{"week", "2024-W27"},
+		{"month", "2024-07"},
+	}
+
+	for _, tt := range tests {
+		if key, err := spendingGroupKey(tx, tt.groupBy); err != nil || key != tt.expected {
+			t.Errorf("Group by %s: expected %q, got %q (%v)", tt.groupBy, tt.expected, key, err)
+		}
+	}
+
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	report, err := buildSpendingReport([]Transaction{tx}, from, from.AddDate(0, 1, 0), from.AddDate(0, -1, 0), from, "month")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Groups) != 1 || report.Groups[0].Key != "2024-07" {
+		t.Errorf("Expected the transaction in the July group, got %+v", report.Groups)
+	}
+}
+
+func TestSpendingGroupKeyInvalid(t *testing.T) {
+	if _, err := spendingGroupKey(Transaction{}, "year"); err == nil {
+		t.Error("Expected error for invalid group-by value, got nil")
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2024, 2, 20, 15, 30, 0, 0, time.UTC)
+
+	from, to, err := parseDateRange("", "", now)
+	if err != nil {
+		t.Fatalf("Failed to parse default range: %v", err)
+	}
+
+	if !from.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected default from to be start of month, got %s", from)
+	}
+
+	if !to.Equal(time.Date(2024, 2, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected default to to be end of today, got %s", to)
+	}
+
+	if _, _, err := parseDateRange("2024-02-10", "2024-02-01", now); err == nil {
+		t.Error("Expected error when from is after to, got nil")
+	}
+
+	if _, _, err := parseDateRange("10/02/2024", "", now); err == nil {
+		t.Error("Expected error for invalid date format, got nil")
+	}
+}
+
+func TestBuildFXReport(t *testing.T) {
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-05-01T10:00:00Z", Amount: -1000, Currency: "GBP", LocalAmount: -1160, LocalCurrency: "EUR"},
+		{ID: "tx_2", Created: "2024-05-02T10:00:00Z", Amount: -2000, Currency: "GBP", LocalAmount: -2340, LocalCurrency: "EUR"},
+		{ID: "tx_3", Created: "2024-05-03T10:00:00Z", Amount: -1000, Currency: "GBP", LocalAmount: -1000, LocalCurrency: "EUR"},
+		// A second trip to the eurozone a month later
+		{ID: "tx_4", Created: "2024-06-10T10:00:00Z", Amount: -500, Currency: "GBP", LocalAmount: -585, LocalCurrency: "EUR"},
+		// Yen have no minor units
+		{ID: "tx_5", Created: "2024-07-01T10:00:00Z", Amount: -1000, Currency: "GBP", LocalAmount: -1950, LocalCurrency: "JPY"},
+		// Domestic and declined transactions are ignored
+		{ID: "tx_6", Created: "2024-05-02T10:00:00Z", Amount: -300, Currency: "GBP", LocalAmount: -300, LocalCurrency: "GBP"},
+		{ID: "tx_7", Created: "2024-05-02T10:00:00Z", Amount: -300, Currency: "GBP", LocalAmount: -350, LocalCurrency: "EUR", DeclineReason: "CARD_BLOCKED"},
+	}
+
+	report := buildFXReport(transactions, 3*24*time.Hour, 0.05)
+
+	if len(report.Currencies) != 2 {
+		t.Fatalf("Expected 2 currencies, got %d", len(report.Currencies))
+	}
+
+	eur := report.Currencies[0]
+	if eur.LocalCurrency != "EUR" || eur.Count != 4 || eur.Total != 4500 || eur.LocalTotal != 5085 {
+		t.Errorf("Unexpected EUR summary: %+v", eur)
+	}
+
+	if eur.MedianRate < 1.165 || eur.MedianRate > 1.175 {
+		t.Errorf("Expected EUR median rate around 1.17, got %f", eur.MedianRate)
+	}
+
+	for _, tx := range eur.Transactions {
+		if tx.Outlier != (tx.ID == "tx_3") {
+			t.Errorf("Unexpected outlier flag %v for %s at rate %f", tx.Outlier, tx.ID, tx.Rate)
+		}
+	}
+
+	jpy := report.Currencies[1]
+	if jpy.MedianRate != 195 {
+		t.Errorf("Expected JPY rate 195, got %f", jpy.MedianRate)
+	}
+
+	if len(report.Trips) != 3 {
+		t.Fatalf("Expected 3 trips, got %d: %+v", len(report.Trips), report.Trips)
+	}
+
+	if report.Trips[0].Count != 3 || report.Trips[1].Count != 1 {
+		t.Errorf("Unexpected trips: %+v", report.Trips)
+	}
+}
+
+func TestBuildDeclinesReport(t *testing.T) {
+	// Days are grouped in local time, which is behind UTC here
+	originalLocal := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	t.Cleanup(func() { time.Local = originalLocal })
+
+	store := &Merchant{ID: "merch_store", Name: "Online Store"}
+	atm := &Merchant{ID: "merch_atm", Name: "Cash machine"}
+
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-01-03T20:00:00Z", Amount: -25000, Currency: "GBP", Merchant: store, DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_2", Created: "2024-01-01T14:00:00Z", Amount: -10000, Currency: "GBP", Merchant: atm, DeclineReason: "INVALID_PIN"},
+		{ID: "tx_3", Created: "2024-01-03T21:00:00Z", Amount: -5000, Currency: "EUR", Merchant: store, DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_4", Created: "2024-01-05T03:00:00Z", Amount: -300, Currency: "GBP", Description: "TFL", DeclineReason: "SOMETHING_NEW"},
+		{ID: "tx_5", Created: "2024-01-02T12:00:00Z", Amount: -450, Currency: "GBP", Merchant: store, IncludeInSpending: true},
+	}
+
+	report := buildDeclinesReport(transactions, nil)
+	expectedTotals := []CurrencyTotal{{Currency: "EUR", Amount: 5000, Count: 1}, {Currency: "GBP", Amount: 35300, Count: 3}}
+	if report.Count != 4 || !slices.Equal(report.Totals, expectedTotals) {
+		t.Fatalf("Unexpected totals: %d declines, %+v", report.Count, report.Totals)
+	}
+	if report.Declines[0].ID != "tx_2" || report.Declines[0].Reason != "incorrect PIN" || report.Declines[0].Amount != 10000 {
+		t.Errorf("Expected the oldest decline first, got %+v", report.Declines[0])
+	}
+	if report.Declines[3].Reason != "something new" {
+		t.Errorf("Expected unknown reasons to be readable, got %q", report.Declines[3].Reason)
+	}
+	expectedReasons := []DeclinesGroup{
+		{Key: "incorrect PIN", Currency: "GBP", Count: 1, Amount: 10000},
+		{Key: "insufficient funds", Currency: "EUR", Count: 1, Amount: 5000},
+		{Key: "insufficient funds", Currency: "GBP", Count: 1, Amount: 25000},
+		{Key: "something new", Currency: "GBP", Count: 1, Amount: 300},
+	}
+	if !slices.Equal(report.ByReason, expectedReasons) {
+		t.Errorf("Expected reasons grouped by currency, got %+v", report.ByReason)
+	}
+	if len(report.ByMerchant) != 4 || report.ByMerchant[0].Key != "Cash machine" {
+		t.Errorf("Unexpected merchants: %+v", report.ByMerchant)
+	}
+	expectedDays := []DeclinesGroup{
+		{Key: "2024-01-01", Currency: "GBP", Count: 1, Amount: 10000},
+		{Key: "2024-01-03", Currency: "EUR", Count: 1, Amount: 5000},
+		{Key: "2024-01-03", Currency: "GBP", Count: 1, Amount: 25000},
+		{Key: "2024-01-04", Currency: "GBP", Count: 1, Amount: 300},
+	}
+	if !slices.Equal(report.ByDay, expectedDays) {
+		t.Errorf("Expected declines grouped by local day in date order, got %+v", report.ByDay)
+	}
+	if day := report.Declines[3].Created.Format("2006-01-02 15:04"); day != "2024-01-04 22:00" {
+		t.Errorf("Expected declines listed in local time, got %s", day)
+	}
+
+	report = buildDeclinesReport(transactions, []string{"tx_1", "tx_2", "tx_3"})
+	if report.Count != 1 || report.Declines[0].ID != "tx_4" {
+		t.Errorf("Expected only declines not seen before, got %+v", report.Declines)
+	}
+}
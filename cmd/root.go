@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -13,12 +18,68 @@ var rootCmd = &cobra.Command{
 	Long: `go-monzo is a command line interface for interacting with the Monzo
 personal banking API. It allows you to access your account information,
 transactions, and other banking features from the terminal.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if apiTimeout <= 0 {
+			return fmt.Errorf("--timeout must be positive")
+		}
+		return configureEndpoints()
+	},
+}
+
+var (
+	flagAPIBaseURL string
+	flagAuthURL    string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&debugEnabled, "debug", debugFromEnv(), "Log HTTP requests and responses to stderr with secrets redacted (or set MONZO_DEBUG)")
+	rootCmd.PersistentFlags().StringVar(&debugHARFile, "debug-har", "", "Write redacted HTTP traffic to a HAR file")
+	rootCmd.PersistentFlags().StringVar(&flagAPIBaseURL, "api-base-url", "", "Base URL of the Monzo API (or set MONZO_API_BASE_URL or api_base_url in config)")
+	rootCmd.PersistentFlags().DurationVar(&apiTimeout, "timeout", apiTimeout, "Timeout for each request to the Monzo API")
+	rootCmd.PersistentFlags().StringVar(&flagAuthURL, "auth-url", "", "URL of the Monzo login page (or set MONZO_AUTH_URL or auth_url in config)")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel in-flight requests and stop long-running commands on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if harErr := writeDebugHAR(); harErr != nil {
+		fmt.Fprintln(os.Stderr, harErr)
+	}
+
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
+
+// serveUntilDone runs serve until ctx is cancelled, then shuts server down,
+// giving in-flight requests serverShutdownTimeout to finish
+func serveUntilDone(ctx context.Context, server *http.Server, serve func() error) error {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- serve()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintln(os.Stderr, "Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	if err := <-errChan; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const rulesFile = "rules.json"
+
+var (
+	rulesAccountID string
+	rulesOffline   bool
+	rulesShowAll   bool
+	rulesAnnotate  bool
+)
+
+// CategoryRule re-categorises and tags matching transactions locally.
+// Every criterion that is set must match for the rule to apply.
+type CategoryRule struct {
+	Name        string   `json:"name"`
+	Merchant    string   `json:"merchant,omitempty"`    // Merchant name, case-insensitive
+	GroupID     string   `json:"group_id,omitempty"`    // Merchant group ID
+	Description string   `json:"description,omitempty"` // Regular expression matched against the description
+	MinAmount   *int64   `json:"min_amount,omitempty"`  // Minimum amount in minor units, inclusive
+	MaxAmount   *int64   `json:"max_amount,omitempty"`  // Maximum amount in minor units, inclusive
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+
+	descriptionPattern *regexp.Regexp
+}
+
+// RulesConfig represents the rules stored in ~/.go-monzo/rules.json
+type RulesConfig struct {
+	Categories []CategoryRule `json:"categories"`
+	Savings    []SavingsRule  `json:"savings,omitempty"`
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage local transaction rules",
+	Long: `Manage local transaction rules stored in ~/.go-monzo/rules.json.
+
+Category rules re-categorise and tag transactions by merchant name, merchant
+group ID, description regular expression or amount range. They are checked in
+order and the first matching rule wins. Rules are applied to transactions in
+all output, reports and exports. For example:
+
+  {
+    "categories": [
+      {
+        "name": "work lunches",
+        "merchant": "Pret A Manger",
+        "max_amount": 0,
+        "min_amount": -1500,
+        "category": "expenses",
+        "tags": ["work"]
+      }
+    ]
+  }
+
+Savings rules move money into pots when 'go-monzo rules run' is run. A
+round_up rule saves the change from rounding each card payment up to
+round_to minor units (100 by default), a percentage rule saves a percent of
+incoming payments matching a description regular expression or category, and
+a sweep rule moves everything above a balance of above minor units, which must
+be set, into a pot on the last day of the month. Pots are given by ID or name:
+
+  {
+    "savings": [
+      {"name": "round ups", "type": "round_up", "pot": "Holiday"},
+      {"name": "salary", "type": "percentage", "pot": "Rainy day", "percent": 10, "description": "SALARY"},
+      {"name": "sweep", "type": "sweep", "pot": "Rainy day", "above": 50000}
+    ]
+  }`,
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List category and savings rules",
+	RunE:  runRulesList,
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show which rule matches each transaction",
+	Long: `Show which category rule matches each transaction for an account.
+
+With --annotate the resulting category and tags are pushed to Monzo as
+transaction metadata (category_override and tags), so they are visible to other
+tools using the API.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runRulesTest,
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesListCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+
+	rulesTestCmd.Flags().StringVar(&rulesAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	rulesTestCmd.Flags().BoolVar(&rulesOffline, "offline", false, "Read transactions from the local cache instead of the API")
+	rulesTestCmd.Flags().BoolVar(&rulesShowAll, "all", false, "Show transactions that don't match any rule")
+	rulesTestCmd.Flags().BoolVar(&rulesAnnotate, "annotate", false, "Store the result in each matched transaction's metadata")
+
+	_ = rulesTestCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+}
+
+// loadRules loads and validates the rules from ~/.go-monzo/rules.json
+func loadRules() (*RulesConfig, error) {
+	var rules RulesConfig
+	if err := loadStateFile(rulesFile, &rules); err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	for i := range rules.Categories {
+		rule := &rules.Categories[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.Merchant == "" && rule.GroupID == "" && rule.Description == "" && rule.MinAmount == nil && rule.MaxAmount == nil {
+			return nil, fmt.Errorf("rule %q has no match criteria", rule.Name)
+		}
+		if rule.Category == "" && len(rule.Tags) == 0 {
+			return nil, fmt.Errorf("rule %q sets neither a category nor tags", rule.Name)
+		}
+		if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+			return nil, fmt.Errorf("rule %q has min_amount greater than max_amount", rule.Name)
+		}
+		if rule.Description != "" {
+			pattern, err := regexp.Compile(rule.Description)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q has an invalid description pattern: %w", rule.Name, err)
+			}
+			rule.descriptionPattern = pattern
+		}
+	}
+
+	if err := validateSavingsRules(rules.Savings); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// Matches reports whether the rule applies to the transaction
+func (r *CategoryRule) Matches(tx Transaction) bool {
+	if r.Merchant != "" && !strings.EqualFold(r.Merchant, tx.MerchantName()) {
+		return false
+	}
+	if r.GroupID != "" && (tx.Merchant == nil || tx.Merchant.GroupID != r.GroupID) {
+		return false
+	}
+	if r.descriptionPattern != nil && !r.descriptionPattern.MatchString(tx.Description) {
+		return false
+	}
+	if r.MinAmount != nil && tx.Amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && tx.Amount > *r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// Apply sets the rule's category and adds its tags to the transaction
+func (r *CategoryRule) Apply(tx *Transaction) {
+	if r.Category != "" {
+		tx.Category = r.Category
+	}
+	for _, tag := range r.Tags {
+		if !slices.Contains(tx.Tags, tag) {
+			tx.Tags = append(tx.Tags, tag)
+		}
+	}
+}
+
+// matchCategoryRule returns the first rule matching the transaction, or nil
+func matchCategoryRule(rules []CategoryRule, tx Transaction) *CategoryRule {
+	for i := range rules {
+		if rules[i].Matches(tx) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// categorise applies the category rules to the transactions in place
+func categorise(transactions []Transaction) error {
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		if rule := matchCategoryRule(rules.Categories, transactions[i]); rule != nil {
+			rule.Apply(&transactions[i])
+		}
+	}
+
+	return nil
+}
+
+func runRulesList(cmd *cobra.Command, args []string) error {
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+
+	if len(rules.Categories) == 0 && len(rules.Savings) == 0 {
+		fmt.Println("No rules defined. Add them to ~/.go-monzo/rules.json.")
+		return nil
+	}
+
+	if len(rules.Categories) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tMATCH\tCATEGORY\tTAGS")
+		for _, rule := range rules.Categories {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.Name, describeRuleMatch(rule), rule.Category, strings.Join(rule.Tags, ","))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(rules.Savings) > 0 {
+		if len(rules.Categories) > 0 {
+			fmt.Println()
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tPOT\tRULE")
+		for _, rule := range rules.Savings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.Name, rule.Type, rule.Pot, describeSavingsRule(rule))
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func describeRuleMatch(rule CategoryRule) string {
+	var parts []string
+	if rule.Merchant != "" {
+		parts = append(parts, fmt.Sprintf("merchant=%q", rule.Merchant))
+	}
+	if rule.GroupID != "" {
+		parts = append(parts, "group_id="+rule.GroupID)
+	}
+	if rule.Description != "" {
+		parts = append(parts, fmt.Sprintf("description~%q", rule.Description))
+	}
+	if rule.MinAmount != nil {
+		parts = append(parts, fmt.Sprintf("amount>=%d", *rule.MinAmount))
+	}
+	if rule.MaxAmount != nil {
+		parts = append(parts, fmt.Sprintf("amount<=%d", *rule.MaxAmount))
+	}
+	return strings.Join(parts, " ")
+}
+
+func runRulesTest(cmd *cobra.Command, args []string) error {
+	if rulesAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+
+	var token *TokenResponse
+	if !rulesOffline || rulesAnnotate {
+		// Load the stored token
+		token, err = loadToken(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+		}
+	}
+
+	var transactions *TransactionsResponse
+	if rulesOffline {
+		transactions, err = loadTransactionCache(rulesAccountID)
+		if err != nil {
+			return err
+		}
+	} else {
+		transactions, err = fetchTransactions(cmd.Context(), token.AccessToken, rulesAccountID, time.Time{}, time.Time{})
+		if err != nil {
+			return fmt.Errorf("failed to fetch transactions: %w", err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSACTION\tDATE\tMERCHANT\tAMOUNT\tCATEGORY\tRULE\tTAGS")
+
+	matched, annotated := 0, 0
+	for _, tx := range transactions.Transactions {
+		rule := matchCategoryRule(rules.Categories, tx)
+		if rule == nil && !rulesShowAll {
+			continue
+		}
+
+		original := tx.Category
+		category, ruleName := original, "-"
+		if rule != nil {
+			rule.Apply(&tx)
+			matched++
+			ruleName = rule.Name
+			if tx.Category != original {
+				category = original + " -> " + tx.Category
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			tx.ID,
+			tx.CreatedTime().Format(dateLayout),
+			tx.MerchantName(),
+			NewMoney(tx.Amount, tx.Currency).String(),
+			category,
+			ruleName,
+			strings.Join(tx.Tags, ","))
+
+		if rule != nil && rulesAnnotate {
+			metadata := map[string]string{
+				"category_override": tx.Category,
+				"tags":              strings.Join(tx.Tags, ","),
+			}
+			if tx.Metadata["category_override"] == metadata["category_override"] && tx.Metadata["tags"] == metadata["tags"] {
+				continue
+			}
+			if _, err := annotateTransaction(cmd.Context(), token.AccessToken, tx.ID, metadata); err != nil {
+				_ = w.Flush()
+				return fmt.Errorf("failed to annotate transaction %s: %w", tx.ID, err)
+			}
+			annotated++
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d of %d transactions matched a rule\n", matched, len(transactions.Transactions))
+	if rulesAnnotate {
+		fmt.Printf("%d transactions annotated\n", annotated)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRulesFile(t *testing.T, contents string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".go-monzo")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, rulesFile), []byte(contents), 0600); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+}
+
+func TestCategorise(t *testing.T) {
+	writeRulesFile(t, `{
+  "categories": [
+    {"name": "work lunches", "merchant": "pret a manger", "min_amount": -1500, "max_amount": 0, "category": "expenses", "tags": ["work"]},
+    {"name": "coffee", "group_id": "grp_pret", "tags": ["coffee"]},
+    {"name": "rent", "description": "^RENT ", "category": "bills"}
+  ]
+}`)
+
+	transactions := []Transaction{
+		{ID: "tx_lunch", Amount: -850, Category: "eating_out", Merchant: &Merchant{GroupID: "grp_pret", Name: "Pret A Manger"}},
+		{ID: "tx_party", Amount: -4500, Category: "eating_out", Merchant: &Merchant{GroupID: "grp_pret", Name: "Pret A Manger"}},
+		{ID: "tx_rent", Amount: -120000, Category: "transfers", Description: "RENT JANUARY"},
+		{ID: "tx_other", Amount: -500, Category: "shopping", Description: "Corner shop"},
+	}
+
+	if err := categorise(transactions); err != nil {
+		t.Fatalf("Failed to categorise: %v", err)
+	}
+
+	if transactions[0].Category != "expenses" || len(transactions[0].Tags) != 1 || transactions[0].Tags[0] != "work" {
+		t.Errorf("Expected work lunch to be re-categorised, got %s %v", transactions[0].Category, transactions[0].Tags)
+	}
+
+	// Outside the amount range, so the second rule matches instead
+	if transactions[1].Category != "eating_out" || len(transactions[1].Tags) != 1 || transactions[1].Tags[0] != "coffee" {
+		t.Errorf("Expected large Pret spend to only be tagged, got %s %v", transactions[1].Category, transactions[1].Tags)
+	}
+
+	if transactions[2].Category != "bills" {
+		t.Errorf("Expected rent to be re-categorised as bills, got %s", transactions[2].Category)
+	}
+
+	if transactions[3].Category != "shopping" || transactions[3].Tags != nil {
+		t.Errorf("Expected unmatched transaction to be unchanged, got %s %v", transactions[3].Category, transactions[3].Tags)
+	}
+}
+
+func TestLoadRulesNoFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	rules, err := loadRules()
+	if err != nil {
+		t.Fatalf("Expected no error when rules file doesn't exist, got: %v", err)
+	}
+
+	if len(rules.Categories) != 0 {
+		t.Errorf("Expected no rules, got %d", len(rules.Categories))
+	}
+}
+
+func TestLoadRulesInvalid(t *testing.T) {
+	for _, contents := range []string{
+		`{"categories": [{"name": "no criteria", "category": "bills"}]}`,
+		`{"categories": [{"name": "no action", "merchant": "Tesco"}]}`,
+		`{"categories": [{"name": "bad pattern", "description": "(", "category": "bills"}]}`,
+		`{"categories": [{"name": "bad range", "min_amount": 100, "max_amount": -100, "category": "bills"}]}`,
+	} {
+		writeRulesFile(t, contents)
+		if _, err := loadRules(); err == nil {
+			t.Errorf("Expected error loading %s, got nil", contents)
+		}
+	}
+}
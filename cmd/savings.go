@@ -0,0 +1,520 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const savingsStateFile = "savings.json"
+
+// Savings rule types
+const (
+	savingsRoundUp    = "round_up"
+	savingsPercentage = "percentage"
+	savingsSweep      = "sweep"
+)
+
+var (
+	savingsAccountID string
+	savingsDryRun    bool
+	savingsSince     string
+)
+
+// SavingsRule moves money into a pot. Round-up rules save the change from
+// rounding each card payment up, percentage rules save part of matching
+// incoming payments and sweep rules move the balance above a threshold on the
+// last day of the month.
+type SavingsRule struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`                  // round_up, percentage or sweep
+	Pot         string  `json:"pot"`                   // Pot ID or name, case-insensitive
+	RoundTo     int64   `json:"round_to,omitempty"`    // round_up: minor units to round up to, 100 if unset
+	Percent     float64 `json:"percent,omitempty"`     // percentage: share of the incoming amount to save
+	Description string  `json:"description,omitempty"` // percentage: regular expression matched against the description
+	Category    string  `json:"category,omitempty"`    // percentage: category of the incoming payment
+	Above       *int64  `json:"above,omitempty"`       // sweep: balance in minor units to leave in the account, required
+
+	descriptionPattern *regexp.Regexp
+}
+
+// SavingsMove represents a deposit into a pot planned by a savings rule
+type SavingsMove struct {
+	Rule          string `json:"rule"`
+	PotID         string `json:"pot_id"`
+	PotName       string `json:"pot_name"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Reason        string `json:"reason"`
+	DedupeID      string `json:"dedupe_id"`
+}
+
+// SavingsCheckpoint records how far the savings rules have got for an account
+type SavingsCheckpoint struct {
+	Since         string            `json:"since"` // Created time of the last evaluated transaction
+	TransactionID string            `json:"transaction_id,omitempty"`
+	Evaluated     []string          `json:"evaluated,omitempty"` // Transactions after a pending one already evaluated
+	Swept         map[string]string `json:"swept,omitempty"`     // Rule name to the last month swept, as YYYY-MM
+}
+
+// SavingsState represents the checkpoints stored in ~/.go-monzo/savings.json
+type SavingsState struct {
+	Accounts map[string]*SavingsCheckpoint `json:"accounts"`
+}
+
+var rulesRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the savings rules",
+	Long: `Run the savings rules in ~/.go-monzo/rules.json against an account, moving
+money into pots.
+
+Round-up and percentage rules are evaluated against transactions newer than
+the last run, which is recorded in ~/.go-monzo/savings.json. The first run
+only records where to start from, unless --since gives a date to start from
+instead. Pending transactions are left until they settle, as their amounts
+can still change. Sweep rules move the balance above their threshold on the
+last day of the month, once per month.
+
+Each deposit uses a dedupe ID derived from the rule and the transaction, so a
+run that fails part way through can be repeated without moving money twice.
+Use --dry-run to see the deposits that would be made.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runRulesRun,
+}
+
+func init() {
+	rulesCmd.AddCommand(rulesRunCmd)
+
+	rulesRunCmd.Flags().StringVar(&savingsAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	rulesRunCmd.Flags().BoolVar(&savingsDryRun, "dry-run", false, "Show the deposits that would be made without moving money")
+	rulesRunCmd.Flags().StringVar(&savingsSince, "since", "", "Evaluate transactions from this date (YYYY-MM-DD) instead of the last run")
+
+	_ = rulesRunCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+}
+
+// validateSavingsRules checks the savings rules and compiles their patterns
+func validateSavingsRules(rules []SavingsRule) error {
+	names := make(map[string]bool)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("savings rule %d", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("savings rule name %q is used more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Pot == "" {
+			return fmt.Errorf("savings rule %q has no pot", rule.Name)
+		}
+
+		switch rule.Type {
+		case savingsRoundUp:
+			if rule.RoundTo == 0 {
+				rule.RoundTo = 100
+			}
+			if rule.RoundTo < 0 {
+				return fmt.Errorf("savings rule %q has a negative round_to", rule.Name)
+			}
+		case savingsPercentage:
+			if rule.Percent <= 0 || rule.Percent > 100 {
+				return fmt.Errorf("savings rule %q must have a percent between 0 and 100", rule.Name)
+			}
+			if rule.Description == "" && rule.Category == "" {
+				return fmt.Errorf("savings rule %q must match a description or category", rule.Name)
+			}
+			if rule.Description != "" {
+				pattern, err := regexp.Compile(rule.Description)
+				if err != nil {
+					return fmt.Errorf("savings rule %q has an invalid description pattern: %w", rule.Name, err)
+				}
+				rule.descriptionPattern = pattern
+			}
+		case savingsSweep:
+			// A missing threshold would sweep the whole balance
+			if rule.Above == nil {
+				return fmt.Errorf("savings rule %q must set above, the balance to leave in the account", rule.Name)
+			}
+			if *rule.Above < 0 {
+				return fmt.Errorf("savings rule %q has a negative threshold", rule.Name)
+			}
+		default:
+			return fmt.Errorf("savings rule %q has unknown type %q: must be round_up, percentage or sweep", rule.Name, rule.Type)
+		}
+	}
+	return nil
+}
+
+// describeSavingsRule summarises what a savings rule does
+func describeSavingsRule(rule SavingsRule) string {
+	switch rule.Type {
+	case savingsRoundUp:
+		return fmt.Sprintf("round spending up to %d", rule.RoundTo)
+	case savingsPercentage:
+		var match []string
+		if rule.Description != "" {
+			match = append(match, fmt.Sprintf("description~%q", rule.Description))
+		}
+		if rule.Category != "" {
+			match = append(match, "category="+rule.Category)
+		}
+		return fmt.Sprintf("save %g%% of income with %s", rule.Percent, strings.Join(match, " "))
+	default:
+		return fmt.Sprintf("sweep balance above %d at month end", *rule.Above)
+	}
+}
+
+func runRulesRun(cmd *cobra.Command, args []string) error {
+	if savingsAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	var since time.Time
+	if savingsSince != "" {
+		var err error
+		since, err = time.ParseInLocation(dateLayout, savingsSince, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --since %q: expected YYYY-MM-DD", savingsSince)
+		}
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+
+	if len(rules.Savings) == 0 {
+		fmt.Println("No savings rules defined. Add them to ~/.go-monzo/rules.json.")
+		return nil
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	pots, err := fetchPots(cmd.Context(), token.AccessToken, savingsAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pots: %w", err)
+	}
+
+	rulePots := make(map[string]Pot)
+	for _, rule := range rules.Savings {
+		pot, err := findPot(openPots(pots.Pots), rule.Pot)
+		if err != nil {
+			return fmt.Errorf("savings rule %q: %w", rule.Name, err)
+		}
+		rulePots[rule.Name] = pot
+	}
+
+	state, err := loadSavingsState()
+	if err != nil {
+		return err
+	}
+
+	checkpoint := state.Accounts[savingsAccountID]
+	if checkpoint == nil {
+		checkpoint = &SavingsCheckpoint{}
+	}
+
+	now := time.Now()
+	if since.IsZero() && checkpoint.Since == "" {
+		fmt.Println("First run: savings rules will apply to transactions from now on. Use --since to include earlier transactions.")
+		checkpoint.Since = now.UTC().Format(time.RFC3339Nano)
+	}
+
+	from := Transaction{Created: checkpoint.Since}.CreatedTime()
+	lastID := checkpoint.TransactionID
+	if !since.IsZero() {
+		from, lastID = since, ""
+	}
+
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, savingsAccountID, from, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	newer := checkpoint.advance(transactions.Transactions, from, lastID)
+	moves := planSavings(rules.Savings, rulePots, newer)
+
+	if due := dueSweeps(rules.Savings, checkpoint, now); len(due) > 0 {
+		balance, err := fetchBalance(cmd.Context(), token.AccessToken, savingsAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch balance: %w", err)
+		}
+		moves = append(moves, planSweeps(due, rulePots, balance, moves, now)...)
+	}
+
+	if len(moves) == 0 {
+		fmt.Println("No money to move")
+	} else {
+		printSavingsMoves(moves)
+	}
+
+	if savingsDryRun {
+		if len(moves) > 0 {
+			fmt.Println("\nDry run: no money was moved")
+		}
+		return nil
+	}
+
+	// The checkpoint is only saved once every deposit has succeeded. A failed
+	// run is repeated from the same place and the dedupe IDs stop deposits
+	// that went through from being made again.
+	if err := applySavingsMoves(cmd.Context(), token.AccessToken, savingsAccountID, moves); err != nil {
+		return err
+	}
+	if len(moves) > 0 {
+		fmt.Printf("\nMade %d deposits into pots\n", len(moves))
+	}
+
+	for _, rule := range dueSweeps(rules.Savings, checkpoint, now) {
+		if checkpoint.Swept == nil {
+			checkpoint.Swept = make(map[string]string)
+		}
+		checkpoint.Swept[rule.Name] = now.Format("2006-01")
+	}
+
+	if state.Accounts == nil {
+		state.Accounts = make(map[string]*SavingsCheckpoint)
+	}
+	state.Accounts[savingsAccountID] = checkpoint
+	if err := saveStateFile(savingsStateFile, state); err != nil {
+		return fmt.Errorf("failed to save savings checkpoint: %w", err)
+	}
+	return nil
+}
+
+// loadSavingsState loads the checkpoints from ~/.go-monzo/savings.json
+func loadSavingsState() (*SavingsState, error) {
+	var state SavingsState
+	if err := loadStateFile(savingsStateFile, &state); err != nil {
+		return nil, fmt.Errorf("failed to load savings checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// advance returns the settled transactions from from onwards that haven't been
+// evaluated yet, and moves the checkpoint up to the first pending transaction.
+// Pending transactions can still change or be reversed, so they are left for a
+// later run; settled transactions after them are remembered in Evaluated so
+// they aren't evaluated twice.
+func (c *SavingsCheckpoint) advance(transactions []Transaction, from time.Time, lastID string) []Transaction {
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedTime().Before(sorted[j].CreatedTime()) })
+
+	evaluated := make(map[string]bool, len(c.Evaluated))
+	for _, id := range c.Evaluated {
+		evaluated[id] = true
+	}
+
+	var newer []Transaction
+	var stillEvaluated []string
+	pending := false
+	for _, tx := range sorted {
+		// The API includes transactions at the start time, so skip the one
+		// the last run finished with
+		created := tx.CreatedTime()
+		if created.Before(from) || (created.Equal(from) && tx.ID == lastID) {
+			continue
+		}
+		if tx.IsPending() {
+			pending = true
+			continue
+		}
+
+		if pending {
+			stillEvaluated = append(stillEvaluated, tx.ID)
+		} else {
+			c.Since, c.TransactionID = tx.Created, tx.ID
+		}
+		if !evaluated[tx.ID] {
+			newer = append(newer, tx)
+		}
+	}
+	c.Evaluated = stillEvaluated
+	return newer
+}
+
+// findPot returns the pot with an ID, or with a name regardless of case
+func findPot(pots []Pot, ref string) (Pot, error) {
+	for _, pot := range pots {
+		if pot.ID == ref {
+			return pot, nil
+		}
+	}
+	for _, pot := range pots {
+		if strings.EqualFold(pot.Name, ref) {
+			return pot, nil
+		}
+	}
+	return Pot{}, fmt.Errorf("pot %q not found", ref)
+}
+
+// planSavings returns the deposits the round-up and percentage rules make for
+// the settled transactions, in transaction order
+func planSavings(rules []SavingsRule, pots map[string]Pot, transactions []Transaction) []SavingsMove {
+	var moves []SavingsMove
+	for _, tx := range transactions {
+		if tx.IsDeclined() || tx.IsPending() {
+			continue
+		}
+		for _, rule := range rules {
+			pot := pots[rule.Name]
+			if tx.Currency != pot.Currency {
+				continue
+			}
+
+			var amount int64
+			var reason string
+			switch rule.Type {
+			case savingsRoundUp:
+				// Only card payments are rounded up, not bills paid by direct
+				// debit or transfers
+				if !tx.IsSpending() || !tx.IsCardPayment() {
+					continue
+				}
+				if remainder := -tx.Amount % rule.RoundTo; remainder > 0 {
+					amount = rule.RoundTo - remainder
+				}
+				reason = "round up " + tx.MerchantName()
+			case savingsPercentage:
+				if !rule.matchesIncome(tx) {
+					continue
+				}
+				amount = int64(float64(tx.Amount) * rule.Percent / 100)
+				reason = fmt.Sprintf("%g%% of %s", rule.Percent, tx.MerchantName())
+			default:
+				continue
+			}
+
+			if amount <= 0 {
+				continue
+			}
+			moves = append(moves, SavingsMove{
+				Rule:          rule.Name,
+				PotID:         pot.ID,
+				PotName:       pot.Name,
+				Amount:        amount,
+				Currency:      tx.Currency,
+				TransactionID: tx.ID,
+				Reason:        reason,
+				DedupeID:      savingsDedupeID(rule.Name, tx.ID),
+			})
+		}
+	}
+	return moves
+}
+
+// matchesIncome reports whether a percentage rule applies to a transaction
+func (r *SavingsRule) matchesIncome(tx Transaction) bool {
+	if tx.Amount <= 0 || tx.IsPotTransfer() {
+		return false
+	}
+	if r.descriptionPattern != nil && !r.descriptionPattern.MatchString(tx.Description) {
+		return false
+	}
+	if r.Category != "" && tx.Category != r.Category {
+		return false
+	}
+	return true
+}
+
+// dueSweeps returns the sweep rules that should run: on the last day of the
+// month, if they haven't already run this month
+func dueSweeps(rules []SavingsRule, checkpoint *SavingsCheckpoint, now time.Time) []SavingsRule {
+	if now.AddDate(0, 0, 1).Month() == now.Month() {
+		return nil
+	}
+
+	var due []SavingsRule
+	for _, rule := range rules {
+		if rule.Type == savingsSweep && checkpoint.Swept[rule.Name] != now.Format("2006-01") {
+			due = append(due, rule)
+		}
+	}
+	return due
+}
+
+// planSweeps returns the deposits that bring the balance down to each sweep
+// rule's threshold, after the deposits already planned
+func planSweeps(rules []SavingsRule, pots map[string]Pot, balance *BalanceResponse, planned []SavingsMove, now time.Time) []SavingsMove {
+	available := balance.Balance
+	for _, move := range planned {
+		if move.Currency == balance.Currency {
+			available -= move.Amount
+		}
+	}
+
+	var moves []SavingsMove
+	for _, rule := range rules {
+		pot := pots[rule.Name]
+		if pot.Currency != balance.Currency || available <= *rule.Above {
+			continue
+		}
+
+		amount := available - *rule.Above
+		available -= amount
+		month := now.Format("2006-01")
+		moves = append(moves, SavingsMove{
+			Rule:     rule.Name,
+			PotID:    pot.ID,
+			PotName:  pot.Name,
+			Amount:   amount,
+			Currency: balance.Currency,
+			Reason:   "month end sweep for " + month,
+			DedupeID: savingsDedupeID(rule.Name, month),
+		})
+	}
+	return moves
+}
+
+// savingsDedupeID derives the dedupe ID for a deposit from the rule and the
+// transaction or month that caused it, so repeated runs make the same request
+func savingsDedupeID(rule, source string) string {
+	sum := sha256.Sum256([]byte(rule + "\x00" + source))
+	return "go-monzo-savings-" + hex.EncodeToString(sum[:16])
+}
+
+// applySavingsMoves makes the planned deposits in order
+func applySavingsMoves(ctx context.Context, accessToken, accountID string, moves []SavingsMove) error {
+	for _, move := range moves {
+		if _, err := depositIntoPot(ctx, accessToken, move.PotID, accountID, move.Amount, move.DedupeID); err != nil {
+			return fmt.Errorf("failed to deposit %s into %s for rule %q: %w", NewMoney(move.Amount, move.Currency).String(), move.PotName, move.Rule, err)
+		}
+	}
+	return nil
+}
+
+func printSavingsMoves(moves []SavingsMove) {
+	totals := make(map[string]*CurrencyTotal)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tPOT\tAMOUNT\tTRANSACTION\tREASON")
+	for _, move := range moves {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", move.Rule, move.PotName, NewMoney(move.Amount, move.Currency).String(), move.TransactionID, move.Reason)
+		addCurrencyTotal(totals, move.Currency, move.Amount)
+	}
+	for _, total := range sortedCurrencyTotals(totals) {
+		fmt.Fprintf(w, "TOTAL\t\t%s\t\t\n", NewMoney(total.Amount, total.Currency).String())
+	}
+	_ = w.Flush()
+}
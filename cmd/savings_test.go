@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadRulesValidatesSavingsRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{name: "valid", rules: `{"savings": [{"type": "round_up", "pot": "Holiday"}, {"type": "percentage", "pot": "Rainy day", "percent": 10, "category": "income"}]}`},
+		{name: "unknown type", rules: `{"savings": [{"name": "x", "type": "invest", "pot": "Holiday"}]}`, wantErr: "unknown type"},
+		{name: "no pot", rules: `{"savings": [{"name": "x", "type": "round_up"}]}`, wantErr: "has no pot"},
+		{name: "no income match", rules: `{"savings": [{"name": "x", "type": "percentage", "pot": "Holiday", "percent": 10}]}`, wantErr: "description or category"},
+		{name: "percent too high", rules: `{"savings": [{"name": "x", "type": "percentage", "pot": "Holiday", "percent": 110, "category": "income"}]}`, wantErr: "between 0 and 100"},
+		{name: "sweep without above", rules: `{"savings": [{"name": "x", "type": "sweep", "pot": "Holiday"}]}`, wantErr: "must set above"},
+		{name: "negative above", rules: `{"savings": [{"name": "x", "type": "sweep", "pot": "Holiday", "above": -1}]}`, wantErr: "negative threshold"},
+		{name: "duplicate name", rules: `{"savings": [{"name": "x", "type": "round_up", "pot": "Holiday"}, {"name": "x", "type": "sweep", "pot": "Holiday"}]}`, wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeRulesFile(t, tt.rules)
+
+			rules, err := loadRules()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if rules.Savings[0].Name != "savings rule 1" || rules.Savings[0].RoundTo != 100 {
+					t.Errorf("Expected defaults to be filled in, got %+v", rules.Savings[0])
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPlanSavings(t *testing.T) {
+	rules := []SavingsRule{
+		{Name: "round ups", Type: savingsRoundUp, Pot: "Holiday", RoundTo: 100},
+		{Name: "salary", Type: savingsPercentage, Pot: "Rainy day", Percent: 10, Category: "income"},
+	}
+	if err := validateSavingsRules(rules); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pots := map[string]Pot{
+		"round ups": {ID: "pot_holiday", Name: "Holiday", Currency: "GBP"},
+		"salary":    {ID: "pot_rainy", Name: "Rainy day", Currency: "GBP"},
+	}
+
+	const settled = "2024-06-02T10:00:00Z"
+	transactions := []Transaction{
+		{ID: "tx_coffee", Amount: -450, Currency: "GBP", IncludeInSpending: true, Settled: settled, Scheme: "mastercard"},
+		{ID: "tx_exact", Amount: -400, Currency: "GBP", IncludeInSpending: true, Settled: settled, Scheme: "mastercard"},
+		// Bills paid by direct debit aren't rounded up
+		{ID: "tx_rent", Amount: -150050, Currency: "GBP", IncludeInSpending: true, Settled: settled, Scheme: "bacs"},
+		{ID: "tx_declined", Amount: -250, Currency: "GBP", IncludeInSpending: true, DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_pending", Amount: -199, Currency: "GBP", IncludeInSpending: true, Scheme: "mastercard"},
+		{ID: "tx_pot", Amount: -5010, Currency: "GBP", Metadata: map[string]string{"pot_id": "pot_holiday"}, Settled: settled},
+		{ID: "tx_euros", Amount: -450, Currency: "EUR", IncludeInSpending: true, Settled: settled, Scheme: "mastercard"},
+		{ID: "tx_salary", Amount: 320005, Currency: "GBP", Category: "income", Settled: settled},
+		{ID: "tx_refund", Amount: 1000, Currency: "GBP", Category: "shopping", Settled: settled},
+	}
+
+	moves := planSavings(rules, pots, transactions)
+	if len(moves) != 2 {
+		t.Fatalf("Expected a round-up and a salary deposit, got %+v", moves)
+	}
+	if moves[0].TransactionID != "tx_coffee" || moves[0].PotID != "pot_holiday" || moves[0].Amount != 50 {
+		t.Errorf("Unexpected round-up: %+v", moves[0])
+	}
+	if moves[1].TransactionID != "tx_salary" || moves[1].PotID != "pot_rainy" || moves[1].Amount != 32000 {
+		t.Errorf("Unexpected salary deposit: %+v", moves[1])
+	}
+
+	// Planning again gives the same dedupe IDs, so repeated runs are safe
+	again := planSavings(rules, pots, transactions)
+	if again[0].DedupeID != moves[0].DedupeID || moves[0].DedupeID == moves[1].DedupeID {
+		t.Errorf("Expected stable, distinct dedupe IDs, got %s, %s and %s", moves[0].DedupeID, again[0].DedupeID, moves[1].DedupeID)
+	}
+}
+
+func TestSavingsCheckpointAdvance(t *testing.T) {
+	from := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		{ID: "tx_last", Created: "2024-06-01T09:00:00Z", Settled: "2024-06-01T10:00:00Z"},
+		{ID: "tx_1", Created: "2024-06-01T12:00:00Z", Settled: "2024-06-02T10:00:00Z"},
+		{ID: "tx_pending", Created: "2024-06-02T12:00:00Z"},
+		{ID: "tx_2", Created: "2024-06-03T12:00:00Z", Settled: "2024-06-03T13:00:00Z"},
+	}
+
+	ids := func(transactions []Transaction) string {
+		var ids []string
+		for _, tx := range transactions {
+			ids = append(ids, tx.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	checkpoint := &SavingsCheckpoint{Since: "2024-06-01T09:00:00Z", TransactionID: "tx_last"}
+	newer := checkpoint.advance(transactions, from, "tx_last")
+	if got := ids(newer); got != "[tx_1 tx_2]" {
+		t.Errorf("Expected the settled transactions since the checkpoint, got %s", got)
+	}
+	if checkpoint.TransactionID != "tx_1" || fmt.Sprint(checkpoint.Evaluated) != "[tx_2]" {
+		t.Errorf("Expected the checkpoint to stop before the pending transaction, got %+v", checkpoint)
+	}
+
+	// Nothing is evaluated twice while the transaction is still pending
+	from = Transaction{Created: checkpoint.Since}.CreatedTime()
+	if newer := checkpoint.advance(transactions, from, checkpoint.TransactionID); len(newer) != 0 {
+		t.Errorf("Expected no transactions to be evaluated again, got %s", ids(newer))
+	}
+
+	// Once it settles, only the pending transaction is new
+	transactions[2].Settled = "2024-06-04T10:00:00Z"
+	newer = checkpoint.advance(transactions, from, checkpoint.TransactionID)
+	if got := ids(newer); got != "[tx_pending]" {
+		t.Errorf("Expected the settled transaction to be evaluated, got %s", got)
+	}
+	if checkpoint.TransactionID != "tx_2" || len(checkpoint.Evaluated) != 0 {
+		t.Errorf("Expected the checkpoint to move to the newest transaction, got %+v", checkpoint)
+	}
+}
+
+func TestSweeps(t *testing.T) {
+	above := int64(50000)
+	rules := []SavingsRule{{Name: "sweep", Type: savingsSweep, Pot: "Rainy day", Above: &above}}
+	pots := map[string]Pot{"sweep": {ID: "pot_rainy", Name: "Rainy day", Currency: "GBP"}}
+	monthEnd := time.Date(2024, 6, 30, 20, 0, 0, 0, time.Local)
+
+	if due := dueSweeps(rules, &SavingsCheckpoint{}, monthEnd.AddDate(0, 0, -1)); len(due) != 0 {
+		t.Errorf("Expected no sweeps before the last day of the month, got %+v", due)
+	}
+	if due := dueSweeps(rules, &SavingsCheckpoint{Swept: map[string]string{"sweep": "2024-06"}}, monthEnd); len(due) != 0 {
+		t.Errorf("Expected no sweep twice in a month, got %+v", due)
+	}
+
+	due := dueSweeps(rules, &SavingsCheckpoint{Swept: map[string]string{"sweep": "2024-05"}}, monthEnd)
+	if len(due) != 1 {
+		t.Fatalf("Expected the sweep to be due, got %+v", due)
+	}
+
+	planned := []SavingsMove{{Amount: 1000, Currency: "GBP"}}
+	moves := planSweeps(due, pots, &BalanceResponse{Balance: 80000, Currency: "GBP"}, planned, monthEnd)
+	if len(moves) != 1 || moves[0].Amount != 29000 {
+		t.Fatalf("Expected the balance above the threshold after other deposits to be swept, got %+v", moves)
+	}
+	if moves[0].DedupeID != savingsDedupeID("sweep", "2024-06") {
+		t.Errorf("Expected the sweep's dedupe ID to be derived from the month, got %s", moves[0].DedupeID)
+	}
+
+	if moves := planSweeps(due, pots, &BalanceResponse{Balance: 40000, Currency: "GBP"}, nil, monthEnd); len(moves) != 0 {
+		t.Errorf("Expected nothing to sweep below the threshold, got %+v", moves)
+	}
+}
@@ -0,0 +1,740 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+const splitsFile = "splits.json"
+
+var (
+	splitAccountID string
+	splitMyShare   int
+	splitCurrency  string
+	splitNote      string
+	splitDryRun    bool
+	splitOutput    string
+)
+
+// SplitShare represents one person's part of a shared transaction
+type SplitShare struct {
+	Person string `json:"person"`
+	Weight int    `json:"weight"`
+	Amount int64  `json:"amount"` // Minor units owed
+}
+
+// Split represents a transaction whose cost is shared with other people
+type Split struct {
+	TransactionID string       `json:"transaction_id"`
+	AccountID     string       `json:"account_id"`
+	Created       string       `json:"created"`
+	Description   string       `json:"description"`
+	Amount        int64        `json:"amount"` // Total cost in minor units
+	Currency      string       `json:"currency"`
+	MyWeight      int          `json:"my_weight"`
+	MyAmount      int64        `json:"my_amount"`
+	Shares        []SplitShare `json:"shares"`
+}
+
+// Settlement represents money paid back by a person. Negative amounts are
+// money paid to them. Settlements found by 'split sync' record the incoming
+// transaction so it is only counted once.
+type Settlement struct {
+	Person        string `json:"person"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Date          string `json:"date"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+// SplitLedger represents the shared expenses stored in ~/.go-monzo/splits.json
+type SplitLedger struct {
+	Splits      []Split      `json:"splits"`
+	Settlements []Settlement `json:"settlements"`
+}
+
+// PersonBalance represents what a person owes in one currency. A negative
+// Owes means you owe them.
+type PersonBalance struct {
+	Person   string `json:"person"`
+	Currency string `json:"currency"`
+	Shared   int64  `json:"shared"`
+	Settled  int64  `json:"settled"`
+	Owes     int64  `json:"owes"`
+}
+
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Track expenses shared with other people",
+	Long: `Track what other people owe you for transactions you've paid for on their
+behalf.
+
+Shared transactions and settlements are stored in ~/.go-monzo/splits.json.
+People are identified by name, compared without regard to case, and the name
+is used to recognise their repayments when running 'go-monzo split sync'.`,
+}
+
+var splitAddCmd = &cobra.Command{
+	Use:   "add <transaction-id> <person[:weight]>...",
+	Short: "Share a transaction with other people",
+	Long: `Share the cost of a transaction with one or more people.
+
+The cost is divided by weight. Each person has a weight of 1 unless given
+one after a colon, and your own weight is set with --my-share. For example,
+'go-monzo split add tx_123 Jamie Sam:2' splits a transaction four ways, with
+Sam paying half. Use --my-share 0 for a transaction paid entirely on someone
+else's behalf. Pennies that don't divide evenly are left with you.
+
+Only outgoing payments can be shared.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeSplitAdd,
+	RunE:              runSplitAdd,
+}
+
+var splitRemoveCmd = &cobra.Command{
+	Use:               "remove <transaction-id>",
+	Short:             "Stop sharing a transaction",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSplitTransactions,
+	RunE:              runSplitRemove,
+}
+
+var splitSettleCmd = &cobra.Command{
+	Use:   "settle <person> <amount>",
+	Short: "Record a repayment made outside Monzo",
+	Long: `Record money a person has paid you back outside Monzo, such as in cash.
+
+The amount is given in major units, optionally with a currency, e.g.
+'go-monzo split settle Jamie £12.50'. Amounts without a currency use
+--currency. A negative amount records money you paid to them.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSplitPeople,
+	RunE:              runSplitSettle,
+}
+
+var splitSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Record repayments from incoming transfers",
+	Long: `Look for incoming transfers from people who owe you and record them as
+settlements.
+
+A transfer matches a person when their name appears as whole words in the
+name of the sender or in the payment reference, the transfer is in a currency they owe
+you and it arrived after the first transaction shared with them. Transfers
+that match more than one person who owes you are left for you to settle by
+hand. Each transfer is only ever recorded once, so the command is safe to run
+from cron.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runSplitSync,
+}
+
+var splitListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List shared transactions and settlements",
+	RunE:  runSplitList,
+}
+
+var splitBalancesCmd = &cobra.Command{
+	Use:   "balances",
+	Short: "Show what each person owes",
+	Long: `Show, for each person and currency, the total of their shares, what they've
+paid back and what they still owe. A negative amount means you owe them.`,
+	RunE: runSplitBalances,
+}
+
+func init() {
+	rootCmd.AddCommand(splitCmd)
+	splitCmd.AddCommand(splitAddCmd)
+	splitCmd.AddCommand(splitRemoveCmd)
+	splitCmd.AddCommand(splitSettleCmd)
+	splitCmd.AddCommand(splitSyncCmd)
+	splitCmd.AddCommand(splitListCmd)
+	splitCmd.AddCommand(splitBalancesCmd)
+
+	splitAddCmd.Flags().IntVar(&splitMyShare, "my-share", 1, "Your weight in the split")
+
+	splitSettleCmd.Flags().StringVar(&splitCurrency, "currency", "GBP", "Currency of the settlement when the amount doesn't specify one")
+	splitSettleCmd.Flags().StringVar(&splitNote, "note", "", "Note to record with the settlement")
+
+	splitSyncCmd.Flags().StringVar(&splitAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	splitSyncCmd.Flags().BoolVar(&splitDryRun, "dry-run", false, "Show the settlements that would be recorded without saving them")
+
+	splitListCmd.Flags().StringVarP(&splitOutput, "output", "o", "table", "Output format: table or json")
+	splitBalancesCmd.Flags().StringVarP(&splitOutput, "output", "o", "table", "Output format: table or json")
+
+	_ = splitSettleCmd.RegisterFlagCompletionFunc("currency", completeCurrencies)
+	_ = splitSyncCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = splitListCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+	_ = splitBalancesCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+}
+
+// completeSplitTransactions suggests the IDs of shared transactions
+func completeSplitTransactions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ids := make([]string, 0, len(ledger.Splits))
+	for _, split := range ledger.Splits {
+		ids = append(ids, split.TransactionID+"\t"+split.Description)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSplitAdd suggests recent transactions for the first argument and the
+// people in the ledger after it
+func completeSplitAdd(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeTransactionIDs(cmd, args, toComplete)
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return ledger.people(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSplitPeople suggests the people in the ledger
+func completeSplitPeople(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return ledger.people(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// loadSplitLedger loads the ledger from ~/.go-monzo/splits.json
+func loadSplitLedger() (*SplitLedger, error) {
+	var ledger SplitLedger
+	if err := loadStateFile(splitsFile, &ledger); err != nil {
+		return nil, fmt.Errorf("failed to load split ledger: %w", err)
+	}
+	return &ledger, nil
+}
+
+func runSplitAdd(cmd *cobra.Command, args []string) error {
+	if splitMyShare < 0 {
+		return fmt.Errorf("--my-share must not be negative")
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	transactionID := args[0]
+	if ledger.split(transactionID) != nil {
+		return fmt.Errorf("transaction %s is already shared. Use 'go-monzo split remove %s' first", transactionID, transactionID)
+	}
+
+	var shares []SplitShare
+	seen := make(map[string]bool)
+	for _, arg := range args[1:] {
+		share, err := parseSplitShare(arg)
+		if err != nil {
+			return err
+		}
+		share.Person = ledger.canonicalName(share.Person)
+		if seen[strings.ToLower(share.Person)] {
+			return fmt.Errorf("%s is listed more than once", share.Person)
+		}
+		seen[strings.ToLower(share.Person)] = true
+		shares = append(shares, share)
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	tx, err := fetchTransaction(cmd.Context(), token.AccessToken, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	if tx.Amount >= 0 || tx.IsDeclined() {
+		return fmt.Errorf("transaction %s isn't an outgoing payment and can't be shared", transactionID)
+	}
+
+	split, err := newSplit(*tx, splitMyShare, shares)
+	if err != nil {
+		return err
+	}
+
+	ledger.Splits = append(ledger.Splits, split)
+	sort.SliceStable(ledger.Splits, func(i, j int) bool {
+		return ledger.Splits[i].CreatedTime().Before(ledger.Splits[j].CreatedTime())
+	})
+
+	if err := saveStateFile(splitsFile, ledger); err != nil {
+		return fmt.Errorf("failed to save split ledger: %w", err)
+	}
+
+	fmt.Printf("Shared %s (%s):\n", split.Description, NewMoney(split.Amount, split.Currency).String())
+	for _, share := range split.Shares {
+		fmt.Printf("  %s owes %s\n", share.Person, NewMoney(share.Amount, split.Currency).String())
+	}
+	fmt.Printf("  Your share is %s\n", NewMoney(split.MyAmount, split.Currency).String())
+	return nil
+}
+
+func runSplitRemove(cmd *cobra.Command, args []string) error {
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	kept := ledger.Splits[:0]
+	for _, split := range ledger.Splits {
+		if split.TransactionID != args[0] {
+			kept = append(kept, split)
+		}
+	}
+
+	if len(kept) == len(ledger.Splits) {
+		return fmt.Errorf("transaction %s isn't shared", args[0])
+	}
+	ledger.Splits = kept
+
+	if err := saveStateFile(splitsFile, ledger); err != nil {
+		return fmt.Errorf("failed to save split ledger: %w", err)
+	}
+
+	fmt.Printf("Transaction %s is no longer shared\n", args[0])
+	return nil
+}
+
+func runSplitSettle(cmd *cobra.Command, args []string) error {
+	amount, err := ParseMoney(args[1], splitCurrency)
+	if err != nil {
+		return err
+	}
+	if amount.Amount == 0 {
+		return fmt.Errorf("settlement amount must not be zero")
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	person := strings.TrimSpace(args[0])
+	if person == "" {
+		return fmt.Errorf("person must not be empty")
+	}
+	person = ledger.canonicalName(person)
+
+	ledger.Settlements = append(ledger.Settlements, Settlement{
+		Person:   person,
+		Amount:   amount.Amount,
+		Currency: amount.Currency,
+		Date:     time.Now().UTC().Format(time.RFC3339),
+		Note:     splitNote,
+	})
+
+	if err := saveStateFile(splitsFile, ledger); err != nil {
+		return fmt.Errorf("failed to save split ledger: %w", err)
+	}
+
+	fmt.Printf("Recorded %s from %s\n", amount.String(), person)
+	return nil
+}
+
+func runSplitSync(cmd *cobra.Command, args []string) error {
+	if splitAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	if len(ledger.Splits) == 0 {
+		fmt.Println("No shared transactions. Use 'go-monzo split add' to share one.")
+		return nil
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	// Repayments can't arrive before the first shared transaction. Every page
+	// since then is searched, as older repayments may not be recorded yet.
+	var since time.Time
+	for _, split := range ledger.Splits {
+		if created := split.CreatedTime(); since.IsZero() || created.Before(since) {
+			since = created
+		}
+	}
+
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, splitAccountID, since, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	settlements := ledger.matchSettlements(transactions.Transactions)
+	if len(settlements) == 0 {
+		fmt.Println("No new repayments found")
+		return nil
+	}
+
+	for _, settlement := range settlements {
+		fmt.Printf("%s paid %s (%s)\n", settlement.Person, NewMoney(settlement.Amount, settlement.Currency).String(), settlement.Note)
+	}
+
+	if splitDryRun {
+		return nil
+	}
+
+	ledger.Settlements = append(ledger.Settlements, settlements...)
+	if err := saveStateFile(splitsFile, ledger); err != nil {
+		return fmt.Errorf("failed to save split ledger: %w", err)
+	}
+	return nil
+}
+
+func runSplitList(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(splitOutput); err != nil {
+		return err
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	if splitOutput == "json" {
+		output, err := json.MarshalIndent(ledger, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal split ledger: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(ledger.Splits) == 0 && len(ledger.Settlements) == 0 {
+		fmt.Println("No shared transactions. Use 'go-monzo split add' to share one.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tTRANSACTION\tDESCRIPTION\tAMOUNT\tSHARES")
+	for _, split := range ledger.Splits {
+		var shares []string
+		for _, share := range split.Shares {
+			shares = append(shares, fmt.Sprintf("%s %s", share.Person, NewMoney(share.Amount, split.Currency).String()))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatSplitDate(split.Created), split.TransactionID, split.Description,
+			NewMoney(split.Amount, split.Currency).String(), strings.Join(shares, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(ledger.Settlements) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tPERSON\tAMOUNT\tTRANSACTION\tNOTE")
+	for _, settlement := range ledger.Settlements {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatSplitDate(settlement.Date), settlement.Person,
+			NewMoney(settlement.Amount, settlement.Currency).String(), settlement.TransactionID, settlement.Note)
+	}
+	return w.Flush()
+}
+
+func runSplitBalances(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(splitOutput); err != nil {
+		return err
+	}
+
+	ledger, err := loadSplitLedger()
+	if err != nil {
+		return err
+	}
+
+	balances := ledger.balances()
+
+	if splitOutput == "json" {
+		output, err := json.MarshalIndent(balances, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal balances: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(balances) == 0 {
+		fmt.Println("No shared transactions. Use 'go-monzo split add' to share one.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERSON\tSHARED\tSETTLED\tOWES")
+	for _, balance := range balances {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", balance.Person,
+			NewMoney(balance.Shared, balance.Currency).String(),
+			NewMoney(balance.Settled, balance.Currency).String(),
+			NewMoney(balance.Owes, balance.Currency).String())
+	}
+	return w.Flush()
+}
+
+// parseSplitShare parses a person with an optional weight, e.g. "Sam:2"
+func parseSplitShare(arg string) (SplitShare, error) {
+	person, weight := arg, 1
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		n, err := strconv.Atoi(arg[i+1:])
+		if err != nil || n <= 0 {
+			return SplitShare{}, fmt.Errorf("invalid weight in %q: must be a positive whole number", arg)
+		}
+		person, weight = arg[:i], n
+	}
+
+	person = strings.TrimSpace(person)
+	if person == "" {
+		return SplitShare{}, fmt.Errorf("invalid share %q: person must not be empty", arg)
+	}
+	return SplitShare{Person: person, Weight: weight}, nil
+}
+
+// newSplit divides the cost of a transaction between you and the people
+// sharing it in proportion to their weights. Each person's share is rounded
+// down and the remainder is added to yours.
+func newSplit(tx Transaction, myWeight int, shares []SplitShare) (Split, error) {
+	if len(shares) == 0 {
+		return Split{}, fmt.Errorf("at least one person is required")
+	}
+
+	total := int64(myWeight)
+	for _, share := range shares {
+		total += int64(share.Weight)
+	}
+
+	amount := -tx.Amount
+	split := Split{
+		TransactionID: tx.ID,
+		AccountID:     tx.AccountID,
+		Created:       tx.Created,
+		Description:   tx.MerchantName(),
+		Amount:        amount,
+		Currency:      tx.Currency,
+		MyWeight:      myWeight,
+		MyAmount:      amount,
+	}
+	for _, share := range shares {
+		share.Amount = amount * int64(share.Weight) / total
+		split.MyAmount -= share.Amount
+		split.Shares = append(split.Shares, share)
+	}
+	return split, nil
+}
+
+// CreatedTime returns the time the shared transaction was created, or the
+// zero time if the timestamp can't be parsed
+func (s Split) CreatedTime() time.Time {
+	return Transaction{Created: s.Created}.CreatedTime()
+}
+
+// split returns the shared transaction with an ID, or nil
+func (l *SplitLedger) split(transactionID string) *Split {
+	for i := range l.Splits {
+		if l.Splits[i].TransactionID == transactionID {
+			return &l.Splits[i]
+		}
+	}
+	return nil
+}
+
+// people returns the names of everyone in the ledger, sorted
+func (l *SplitLedger) people() []string {
+	seen := make(map[string]bool)
+	var people []string
+	add := func(person string) {
+		if !seen[person] {
+			seen[person] = true
+			people = append(people, person)
+		}
+	}
+	for _, split := range l.Splits {
+		for _, share := range split.Shares {
+			add(share.Person)
+		}
+	}
+	for _, settlement := range l.Settlements {
+		add(settlement.Person)
+	}
+	sort.Slice(people, func(i, j int) bool { return strings.ToLower(people[i]) < strings.ToLower(people[j]) })
+	return people
+}
+
+// canonicalName returns the spelling of a name already in the ledger that
+// matches regardless of case, or the name itself for someone new
+func (l *SplitLedger) canonicalName(name string) string {
+	for _, person := range l.people() {
+		if strings.EqualFold(person, name) {
+			return person
+		}
+	}
+	return name
+}
+
+// balances returns what each person owes in each currency, ordered by person
+// and currency
+func (l *SplitLedger) balances() []PersonBalance {
+	type key struct{ person, currency string }
+	totals := make(map[key]*PersonBalance)
+	get := func(person, currency string) *PersonBalance {
+		k := key{person, currency}
+		if totals[k] == nil {
+			totals[k] = &PersonBalance{Person: person, Currency: currency}
+		}
+		return totals[k]
+	}
+
+	for _, split := range l.Splits {
+		for _, share := range split.Shares {
+			get(share.Person, split.Currency).Shared += share.Amount
+		}
+	}
+	for _, settlement := range l.Settlements {
+		get(settlement.Person, settlement.Currency).Settled += settlement.Amount
+	}
+
+	balances := make([]PersonBalance, 0, len(totals))
+	for _, balance := range totals {
+		balance.Owes = balance.Shared - balance.Settled
+		balances = append(balances, *balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if a, b := strings.ToLower(balances[i].Person), strings.ToLower(balances[j].Person); a != b {
+			return a < b
+		}
+		return balances[i].Currency < balances[j].Currency
+	})
+	return balances
+}
+
+// matchSettlements returns a settlement for each incoming transfer from a
+// person who owes money in its currency, in date order. Transfers already
+// recorded, transfers that match more than one person and transfers made
+// before the person's first shared transaction are ignored.
+func (l *SplitLedger) matchSettlements(transactions []Transaction) []Settlement {
+	recorded := make(map[string]bool)
+	for _, settlement := range l.Settlements {
+		if settlement.TransactionID != "" {
+			recorded[settlement.TransactionID] = true
+		}
+	}
+
+	owes := make(map[string]int64)
+	for _, balance := range l.balances() {
+		owes[balance.Person+"|"+balance.Currency] = balance.Owes
+	}
+
+	firstShared := make(map[string]time.Time)
+	for _, split := range l.Splits {
+		for _, share := range split.Shares {
+			if first, ok := firstShared[share.Person]; !ok || split.CreatedTime().Before(first) {
+				firstShared[share.Person] = split.CreatedTime()
+			}
+		}
+	}
+
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedTime().Before(sorted[j].CreatedTime()) })
+
+	var settlements []Settlement
+	for _, tx := range sorted {
+		if tx.Amount <= 0 || tx.IsDeclined() || tx.IsPotTransfer() || recorded[tx.ID] {
+			continue
+		}
+
+		from := nameWords(tx.Description)
+		if tx.Counterparty != nil {
+			from = append(nameWords(tx.Counterparty.Name), from...)
+		}
+
+		var matches []string
+		for person, first := range firstShared {
+			if owes[person+"|"+tx.Currency] > 0 && !tx.CreatedTime().Before(first) && containsWords(from, nameWords(person)) {
+				matches = append(matches, person)
+			}
+		}
+		if len(matches) != 1 {
+			continue
+		}
+
+		person := matches[0]
+		owes[person+"|"+tx.Currency] -= tx.Amount
+		settlements = append(settlements, Settlement{
+			Person:        person,
+			Amount:        tx.Amount,
+			Currency:      tx.Currency,
+			Date:          tx.Created,
+			TransactionID: tx.ID,
+			Note:          tx.Description,
+		})
+	}
+	return settlements
+}
+
+// nameWords splits a name or reference into lower case words
+func nameWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords reports whether words contains the words of name in order, so
+// that "Al" matches "Al Smith" but not "ACME SALARY"
+func containsWords(words, name []string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i+len(name) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(name)], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSplitDate formats an RFC 3339 timestamp as a date
+func formatSplitDate(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.Format("2006-01-02")
+}
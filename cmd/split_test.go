@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestParseSplitShare(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    SplitShare
+		wantErr bool
+	}{
+		{arg: "Jamie", want: SplitShare{Person: "Jamie", Weight: 1}},
+		{arg: "Sam:2", want: SplitShare{Person: "Sam", Weight: 2}},
+		{arg: "Sam:0", wantErr: true},
+		{arg: "Sam:half", wantErr: true},
+		{arg: ":2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSplitShare(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSplitShare(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseSplitShare(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestNewSplitLeavesRemainderWithMe(t *testing.T) {
+	tx := Transaction{ID: "tx_1", Created: "2024-06-01T19:00:00Z", Description: "LE PETIT BISTRO", Amount: -1000, Currency: "GBP"}
+
+	split, err := newSplit(tx, 1, []SplitShare{{Person: "Jamie", Weight: 1}, {Person: "Sam", Weight: 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if split.Amount != 1000 || split.Shares[0].Amount != 333 || split.Shares[1].Amount != 333 || split.MyAmount != 334 {
+		t.Errorf("Expected shares of 333 with 334 left with me, got %+v", split)
+	}
+
+	split, err = newSplit(tx, 0, []SplitShare{{Person: "Jamie", Weight: 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if split.Shares[0].Amount != 1000 || split.MyAmount != 0 {
+		t.Errorf("Expected Jamie to owe the whole amount, got %+v", split)
+	}
+}
+
+func TestSplitLedgerBalancesAndSettlements(t *testing.T) {
+	ledger := &SplitLedger{
+		Splits: []Split{
+			{TransactionID: "tx_1", Created: "2024-06-01T19:00:00Z", Amount: 3000, Currency: "GBP", Shares: []SplitShare{{Person: "Jamie", Amount: 1500}}},
+			{TransactionID: "tx_2", Created: "2024-06-05T19:00:00Z", Amount: 3000, Currency: "GBP", Shares: []SplitShare{{Person: "Jamie", Amount: 1000}, {Person: "Sam", Amount: 1000}}},
+		},
+		Settlements: []Settlement{{Person: "Jamie", Amount: 500, Currency: "GBP", TransactionID: "tx_paid"}},
+	}
+
+	if name := ledger.canonicalName("jamie"); name != "Jamie" {
+		t.Errorf("Expected names to match regardless of case, got %q", name)
+	}
+
+	transactions := []Transaction{
+		// Already recorded
+		{ID: "tx_paid", Created: "2024-06-02T10:00:00Z", Description: "Dinner", Amount: 500, Currency: "GBP", Counterparty: &Counterparty{Name: "Jamie Friend"}},
+		// Before Sam's first shared transaction
+		{ID: "tx_early", Created: "2024-06-03T10:00:00Z", Description: "from sam", Amount: 1000, Currency: "GBP"},
+		// Matches both people
+		{ID: "tx_both", Created: "2024-06-06T09:00:00Z", Description: "Sam and Jamie", Amount: 100, Currency: "GBP"},
+		{ID: "tx_jamie", Created: "2024-06-06T10:00:00Z", Description: "Thanks!", Amount: 2000, Currency: "GBP", Counterparty: &Counterparty{Name: "JAMIE FRIEND"}},
+		// Jamie no longer owes anything
+		{ID: "tx_jamie_again", Created: "2024-06-07T10:00:00Z", Description: "Jamie", Amount: 100, Currency: "GBP"},
+		{ID: "tx_declined", Created: "2024-06-07T12:00:00Z", Description: "Sam", Amount: 1000, Currency: "GBP", DeclineReason: "OTHER"},
+		// Names only match whole words
+		{ID: "tx_samsung", Created: "2024-06-08T09:00:00Z", Description: "SAMSUNG REFUND", Amount: 300, Currency: "GBP"},
+		{ID: "tx_sam", Created: "2024-06-08T10:00:00Z", Description: "Sam's share", Amount: 400, Currency: "GBP"},
+	}
+
+	settlements := ledger.matchSettlements(transactions)
+	var ids []string
+	for _, settlement := range settlements {
+		ids = append(ids, settlement.Person+":"+settlement.TransactionID)
+	}
+	if fmt.Sprint(ids) != "[Jamie:tx_jamie Sam:tx_sam]" {
+		t.Fatalf("Unexpected settlements: %v", ids)
+	}
+
+	ledger.Settlements = append(ledger.Settlements, settlements...)
+	expected := []PersonBalance{
+		{Person: "Jamie", Currency: "GBP", Shared: 2500, Settled: 2500, Owes: 0},
+		{Person: "Sam", Currency: "GBP", Shared: 1000, Settled: 400, Owes: 600},
+	}
+	if balances := ledger.balances(); fmt.Sprint(balances) != fmt.Sprint(expected) {
+		t.Errorf("Expected balances %+v, got %+v", expected, balances)
+	}
+}
+
+func TestMatchSettlementsShortName(t *testing.T) {
+	ledger := &SplitLedger{Splits: []Split{
+		{TransactionID: "tx_1", Created: "2024-06-01T19:00:00Z", Amount: 3000, Currency: "GBP", Shares: []SplitShare{{Person: "Al", Amount: 1500}}},
+	}}
+
+	transactions := []Transaction{
+		{ID: "tx_salary", Created: "2024-06-25T06:00:00Z", Description: "ACME LTD SALARY", Amount: 320000, Currency: "GBP", Counterparty: &Counterparty{Name: "ACME PAYROLL SALARY"}},
+		{ID: "tx_malt", Created: "2024-06-26T10:00:00Z", Description: "Refund", Amount: 500, Currency: "GBP", Counterparty: &Counterparty{Name: "Malt House"}},
+		{ID: "tx_al", Created: "2024-06-27T10:00:00Z", Description: "dinner", Amount: 1500, Currency: "GBP", Counterparty: &Counterparty{Name: "AL JONES"}},
+	}
+
+	settlements := ledger.matchSettlements(transactions)
+	if len(settlements) != 1 || settlements[0].TransactionID != "tx_al" {
+		t.Errorf("Expected only Al's transfer to match, got %+v", settlements)
+	}
+}
+
+func TestSplitSyncFindsRepaymentsBeyondTheFirstPage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := useMockAPI(t)
+	if err := saveToken(&TokenResponse{AccessToken: monzotest.AccessToken, RefreshToken: monzotest.RefreshToken, ExpiresIn: 3600}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	// Share the oldest transaction, so more than a page of history follows it
+	var oldest monzotest.Transaction
+	for _, tx := range mock.Transactions {
+		if tx.AccountID == monzotest.PersonalAccountID && tx.Amount < 0 {
+			oldest = tx
+			break
+		}
+	}
+	ledger := &SplitLedger{Splits: []Split{{
+		TransactionID: oldest.ID, AccountID: monzotest.PersonalAccountID, Created: oldest.Created,
+		Amount: 4000, Currency: "GBP", Shares: []SplitShare{{Person: "Alex", Amount: 2000}},
+	}}}
+	if err := saveStateFile(splitsFile, ledger); err != nil {
+		t.Fatalf("Failed to save ledger: %v", err)
+	}
+
+	repayment := mock.AddTransaction(monzotest.Transaction{
+		AccountID: monzotest.PersonalAccountID, Created: time.Now().UTC().Format(time.RFC3339Nano),
+		Description: "Lunch", Amount: 2000, Currency: "GBP", Settled: time.Now().UTC().Format(time.RFC3339Nano),
+		Counterparty: &monzotest.Counterparty{Name: "Alex Smith"},
+	})
+
+	originalAccountID, originalDryRun := splitAccountID, splitDryRun
+	splitAccountID, splitDryRun = monzotest.PersonalAccountID, false
+	t.Cleanup(func() { splitAccountID, splitDryRun = originalAccountID, originalDryRun })
+
+	splitSyncCmd.SetContext(context.Background())
+	if err := runSplitSync(splitSyncCmd, nil); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	synced, err := loadSplitLedger()
+	if err != nil {
+		t.Fatalf("Failed to load ledger: %v", err)
+	}
+	if len(synced.Settlements) != 1 || synced.Settlements[0].TransactionID != repayment.ID {
+		t.Errorf("Expected the latest repayment to be recorded, got %+v", synced.Settlements)
+	}
+}
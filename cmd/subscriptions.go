@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// minRecurringRatio is the share of intervals and amounts that must match
+	// for a series of payments to be treated as recurring
+	minRecurringRatio = 0.6
+)
+
+var (
+	subsAccountID string
+	subsMonths    int
+	subsTolerance float64
+	subsOutput    string
+)
+
+// cadence describes how often a recurring payment is made
+type cadence struct {
+	Name    string
+	MinDays float64
+	MaxDays float64
+	Grace   time.Duration // How late a payment can be before it's considered missed
+	next    func(time.Time) time.Time
+}
+
+var cadences = []cadence{
+	{Name: "weekly", MinDays: 6, MaxDays: 8, Grace: 3 * 24 * time.Hour, next: func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }},
+	{Name: "fortnightly", MinDays: 13, MaxDays: 15, Grace: 4 * 24 * time.Hour, next: func(t time.Time) time.Time { return t.AddDate(0, 0, 14) }},
+	{Name: "monthly", MinDays: 27, MaxDays: 33, Grace: 7 * 24 * time.Hour, next: func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{Name: "annual", MinDays: 355, MaxDays: 375, Grace: 30 * 24 * time.Hour, next: func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// RecurringPayment represents a series of payments made at a regular cadence
+type RecurringPayment struct {
+	Name           string    `json:"name"`
+	Cadence        string    `json:"cadence"`
+	Count          int       `json:"count"`
+	Currency       string    `json:"currency"`
+	FirstDate      time.Time `json:"first_date"`
+	PreviousDate   time.Time `json:"previous_date"` // Date of the payment before the last
+	LastDate       time.Time `json:"last_date"`
+	LastAmount     int64     `json:"last_amount"`
+	NextExpected   time.Time `json:"next_expected"`
+	ExpectedAmount int64     `json:"expected_amount"`
+	PriceIncrease  int64     `json:"price_increase,omitempty"`
+	Missed         bool      `json:"missed"`
+}
+
+var subscriptionsCmd = &cobra.Command{
+	Use:   "subscriptions",
+	Short: "Detect recurring payments and subscriptions",
+	Long: `Detect recurring payments and subscriptions from transaction history.
+
+Payments to the same merchant are treated as recurring when they are made at a
+weekly, fortnightly, monthly or annual cadence and their amounts are within the
+given tolerance of each other. For each subscription the next expected payment
+date and amount are shown, and price increases and missed payments are flagged.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runSubscriptions,
+}
+
+func init() {
+	rootCmd.AddCommand(subscriptionsCmd)
+
+	subscriptionsCmd.Flags().StringVar(&subsAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	subscriptionsCmd.Flags().IntVar(&subsMonths, "months", 13, "Number of months of history to analyse")
+	subscriptionsCmd.Flags().Float64Var(&subsTolerance, "tolerance", 0.1, "Allowed variation in amount between payments, as a fraction")
+	subscriptionsCmd.Flags().StringVarP(&subsOutput, "output", "o", "table", "Output format: table or json")
+
+	_ = subscriptionsCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = subscriptionsCmd.RegisterFlagCompletionFunc("output", fixedCompletions("table", "json"))
+}
+
+func runSubscriptions(cmd *cobra.Command, args []string) error {
+	if subsAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if err := validateOutputFormat(subsOutput); err != nil {
+		return err
+	}
+
+	if subsTolerance < 0 || subsTolerance >= 1 {
+		return fmt.Errorf("--tolerance must be between 0 and 1")
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	now := time.Now()
+	transactions, err := fetchTransactions(cmd.Context(), token.AccessToken, subsAccountID, now.AddDate(0, -subsMonths, 0), time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	subscriptions := detectSubscriptions(transactions.Transactions, subsTolerance, now)
+
+	if subsOutput == "json" {
+		output, err := json.MarshalIndent(subscriptions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal subscriptions: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(subscriptions) == 0 {
+		fmt.Println("No recurring payments found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MERCHANT\tCADENCE\tPAYMENTS\tLAST\tAMOUNT\tNEXT\tFLAGS")
+	for _, sub := range subscriptions {
+		flags := ""
+		if sub.PriceIncrease > 0 {
+			flags = "price up " + NewMoney(sub.PriceIncrease, sub.Currency).String()
+		}
+		if sub.Missed {
+			if flags != "" {
+				flags += ", "
+			}
+			flags += "missed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			sub.Name,
+			sub.Cadence,
+			sub.Count,
+			sub.LastDate.Format(dateLayout),
+			NewMoney(sub.ExpectedAmount, sub.Currency).String(),
+			sub.NextExpected.Format(dateLayout),
+			flags)
+	}
+	return w.Flush()
+}
+
+// detectSubscriptions finds recurring outgoing payments, grouped by merchant
+func detectSubscriptions(transactions []Transaction, tolerance float64, now time.Time) []RecurringPayment {
+	groups := make(map[string][]Transaction)
+	for _, tx := range transactions {
+		if tx.Amount >= 0 || tx.IsDeclined() || tx.IsPotTransfer() {
+			continue
+		}
+		key := tx.MerchantName()
+		if tx.Merchant != nil && tx.Merchant.GroupID != "" {
+			key = tx.Merchant.GroupID
+		}
+		groups[key] = append(groups[key], tx)
+	}
+
+	return detectRecurring(groups, Transaction.MerchantName, tolerance, now)
+}
+
+// detectRecurring checks each group of transactions for a regular cadence and
+// consistent amounts. Each result is named after its latest transaction.
+// Amounts in the result are absolute values.
+func detectRecurring(groups map[string][]Transaction, name func(Transaction) string, tolerance float64, now time.Time) []RecurringPayment {
+	var recurring []RecurringPayment
+
+	for _, txs := range groups {
+		if len(txs) < 2 {
+			continue
+		}
+
+		sort.Slice(txs, func(i, j int) bool {
+			return txs[i].CreatedTime().Before(txs[j].CreatedTime())
+		})
+
+		intervals := make([]float64, 0, len(txs)-1)
+		for i := 1; i < len(txs); i++ {
+			intervals = append(intervals, txs[i].CreatedTime().Sub(txs[i-1].CreatedTime()).Hours()/24)
+		}
+
+		c, ok := matchCadence(intervals)
+		if !ok {
+			continue
+		}
+
+		// Two payments are only enough evidence for annual subscriptions
+		if len(txs) < 3 && c.Name != "annual" {
+			continue
+		}
+
+		amounts := make([]float64, len(txs))
+		for i, tx := range txs {
+			amounts[i] = math.Abs(float64(tx.Amount))
+		}
+		typical := median(amounts)
+		consistent := 0
+		for _, amount := range amounts {
+			if math.Abs(amount-typical) <= typical*tolerance {
+				consistent++
+			}
+		}
+		if float64(consistent)/float64(len(amounts)) < minRecurringRatio {
+			continue
+		}
+
+		last := txs[len(txs)-1]
+		previous := txs[len(txs)-2]
+		lastAmount := abs64(last.Amount)
+
+		payment := RecurringPayment{
+			Name:           name(last),
+			Cadence:        c.Name,
+			Count:          len(txs),
+			Currency:       last.Currency,
+			FirstDate:      txs[0].CreatedTime(),
+			PreviousDate:   previous.CreatedTime(),
+			LastDate:       last.CreatedTime(),
+			LastAmount:     lastAmount,
+			NextExpected:   c.next(last.CreatedTime()),
+			ExpectedAmount: lastAmount,
+		}
+
+		if increase := lastAmount - abs64(previous.Amount); increase > 0 {
+			payment.PriceIncrease = increase
+		}
+
+		payment.Missed = now.After(payment.NextExpected.Add(c.Grace))
+
+		recurring = append(recurring, payment)
+	}
+
+	sort.Slice(recurring, func(i, j int) bool {
+		return recurring[i].NextExpected.Before(recurring[j].NextExpected)
+	})
+
+	return recurring
+}
+
+// matchCadence returns the cadence that fits the intervals between payments,
+// given in days
+func matchCadence(intervals []float64) (cadence, bool) {
+	typical := median(intervals)
+
+	for _, c := range cadences {
+		if typical < c.MinDays || typical > c.MaxDays {
+			continue
+		}
+
+		matching := 0
+		for _, interval := range intervals {
+			if interval >= c.MinDays && interval <= c.MaxDays {
+				matching++
+			}
+		}
+
+		if float64(matching)/float64(len(intervals)) >= minRecurringRatio {
+			return c, true
+		}
+	}
+
+	return cadence{}, false
+}
+
+// median returns the median of the values, or 0 if there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectSubscriptionsMonthly(t *testing.T) {
+	netflix := &Merchant{ID: "merch_1", GroupID: "grp_netflix", Name: "Netflix"}
+
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-01-05T09:00:00Z", Amount: -1099, Currency: "GBP", Merchant: netflix},
+		{ID: "tx_2", Created: "2024-02-05T09:00:00Z", Amount: -1099, Currency: "GBP", Merchant: netflix},
+		{ID: "tx_3", Created: "2024-03-05T09:00:00Z", Amount: -1099, Currency: "GBP", Merchant: netflix},
+		{ID: "tx_4", Created: "2024-04-05T09:00:00Z", Amount: -1199, Currency: "GBP", Merchant: netflix},
+		// One-off payments are not subscriptions
+		{ID: "tx_5", Created: "2024-02-17T09:00:00Z", Amount: -4500, Currency: "GBP", Description: "Garden Centre"},
+		// Declined payments are ignored
+		{ID: "tx_6", Created: "2024-03-20T09:00:00Z", Amount: -1099, Currency: "GBP", Merchant: netflix, DeclineReason: "INSUFFICIENT_FUNDS"},
+	}
+
+	now := time.Date(2024, 4, 20, 0, 0, 0, 0, time.UTC)
+	subscriptions := detectSubscriptions(transactions, 0.1, now)
+
+	if len(subscriptions) != 1 {
+		t.Fatalf("Expected 1 subscription, got %d: %+v", len(subscriptions), subscriptions)
+	}
+
+	sub := subscriptions[0]
+	if sub.Name != "Netflix" || sub.Cadence != "monthly" || sub.Count != 4 {
+		t.Errorf("Unexpected subscription: %+v", sub)
+	}
+
+	if !sub.NextExpected.Equal(time.Date(2024, 5, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected next payment on 2024-05-05, got %s", sub.NextExpected)
+	}
+
+	if sub.PriceIncrease != 100 {
+		t.Errorf("Expected price increase of 100, got %d", sub.PriceIncrease)
+	}
+
+	if sub.Missed {
+		t.Error("Expected subscription not to be missed")
+	}
+}
+
+func TestDetectSubscriptionsMissed(t *testing.T) {
+	transactions := []Transaction{
+		{Created: "2024-01-01T09:00:00Z", Amount: -500, Currency: "GBP", Description: "Gym"},
+		{Created: "2024-01-08T09:00:00Z", Amount: -500, Currency: "GBP", Description: "Gym"},
+		{Created: "2024-01-15T09:00:00Z", Amount: -500, Currency: "GBP", Description: "Gym"},
+	}
+
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	subscriptions := detectSubscriptions(transactions, 0.1, now)
+
+	if len(subscriptions) != 1 {
+		t.Fatalf("Expected 1 subscription, got %d", len(subscriptions))
+	}
+
+	if subscriptions[0].Cadence != "weekly" || !subscriptions[0].Missed {
+		t.Errorf("Expected missed weekly subscription, got %+v", subscriptions[0])
+	}
+}
+
+func TestDetectSubscriptionsIrregularAmounts(t *testing.T) {
+	transactions := []Transaction{
+		{Created: "2024-01-01T09:00:00Z", Amount: -500, Currency: "GBP", Description: "Corner Shop"},
+		{Created: "2024-02-01T09:00:00Z", Amount: -2500, Currency: "GBP", Description: "Corner Shop"},
+		{Created: "2024-03-01T09:00:00Z", Amount: -900, Currency: "GBP", Description: "Corner Shop"},
+	}
+
+	subscriptions := detectSubscriptions(transactions, 0.1, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	if len(subscriptions) != 0 {
+		t.Errorf("Expected no subscriptions for irregular amounts, got %+v", subscriptions)
+	}
+}
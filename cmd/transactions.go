@@ -8,15 +8,31 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var txAccountID string
+var (
+	txAccountID string
+	txFilter    string
+	txDeclined  bool
+	txPending   bool
+	txSettled   bool
+	txOnline    bool
+	txATM       bool
+	txCurrency  string
+	txOffline   bool
+	txAll       bool
+	txArchive   string
+)
 
 // Transaction represents a Monzo transaction
 type Transaction struct {
 	ID                     string            `json:"id"`
+	AccountID              string            `json:"account_id,omitempty"`
 	Created                string            `json:"created"`
 	Description            string            `json:"description"`
 	Amount                 int64             `json:"amount"`
@@ -37,17 +53,55 @@ type Transaction struct {
 	CanSplitTheBill        bool              `json:"can_split_the_bill"`
 	CanAddToTab            bool              `json:"can_add_to_tab"`
 	AmountIsPending        bool              `json:"amount_is_pending"`
+	Attachments            []Attachment      `json:"attachments,omitempty"`
+	Counterparty           *Counterparty     `json:"counterparty,omitempty"`
+	Scheme                 string            `json:"scheme"`
+
+	// Tags are added locally by categorisation rules and aren't part of the API
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Counterparty represents the other party to a bank transfer or payment
+// between Monzo users
+type Counterparty struct {
+	Name          string `json:"name,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	SortCode      string `json:"sort_code,omitempty"`
 }
 
 // Merchant represents merchant information for a transaction
 type Merchant struct {
-	ID       string `json:"id"`
-	GroupID  string `json:"group_id"`
-	Name     string `json:"name"`
-	Logo     string `json:"logo"`
-	Category string `json:"category"`
-	Online   bool   `json:"online"`
-	ATM      bool   `json:"atm"`
+	ID       string           `json:"id"`
+	GroupID  string           `json:"group_id"`
+	Name     string           `json:"name"`
+	Logo     string           `json:"logo"`
+	Emoji    string           `json:"emoji,omitempty"`
+	Category string           `json:"category"`
+	Online   bool             `json:"online"`
+	ATM      bool             `json:"atm"`
+	Address  *MerchantAddress `json:"address,omitempty"`
+}
+
+// Attachment represents an image attached to a transaction, such as a receipt
+type Attachment struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	ExternalID string `json:"external_id"`
+	FileURL    string `json:"file_url"`
+	FileType   string `json:"file_type"`
+	Created    string `json:"created"`
+}
+
+// MerchantAddress represents the location of a merchant
+type MerchantAddress struct {
+	Address   string  `json:"address"`
+	City      string  `json:"city"`
+	Region    string  `json:"region"`
+	Postcode  string  `json:"postcode"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 // TransactionsResponse represents the response from the transactions endpoint
@@ -55,6 +109,27 @@ type TransactionsResponse struct {
 	Transactions []Transaction `json:"transactions"`
 }
 
+// AccountTransactionTotals represents the totals of one account's transactions
+type AccountTransactionTotals struct {
+	AccountID   string          `json:"account_id"`
+	AccountName string          `json:"account_name"`
+	AccountType string          `json:"account_type"`
+	Totals      []CurrencyTotal `json:"totals"`
+}
+
+// AllAccountsTransactionsResponse represents the transactions of every open
+// account, with totals for each account and currency
+type AllAccountsTransactionsResponse struct {
+	Transactions []Transaction              `json:"transactions"`
+	Accounts     []AccountTransactionTotals `json:"accounts"`
+	Totals       []CurrencyTotal            `json:"totals"`
+}
+
+// TransactionResponse represents the response from the single transaction endpoint
+type TransactionResponse struct {
+	Transaction Transaction `json:"transaction"`
+}
+
 var transactionsCmd = &cobra.Command{
 	Use:   "transactions",
 	Short: "List transactions for an account",
@@ -63,6 +138,29 @@ var transactionsCmd = &cobra.Command{
 This command retrieves transaction history from the Monzo API
 and outputs the results in JSON format.
 
+Transactions can be narrowed down with the filter flags, or with a filter
+expression evaluated over the transaction and merchant fields, e.g.
+
+  --filter 'amount < -5000 && category == "groceries" && merchant ~ "Tesco"'
+
+Comparisons use == != < <= > >= and ~ / !~ for case-insensitive regular
+expression matches, and can be combined with &&, || and parentheses. Amounts
+are in minor units. Available fields are id, created, description, amount,
+currency, notes, category, settled, account_balance, local_amount,
+local_currency, decline_reason, declined, pending, is_load,
+include_in_spending, merchant, merchant.id, merchant.group_id, merchant.name,
+merchant.category, merchant.online, merchant.atm, tags and metadata.<key>.
+
+Categories and tags reflect the local rules in ~/.go-monzo/rules.json.
+Fetched transactions are saved to a local cache in ~/.go-monzo/cache, which
+--offline reads instead of calling the API. --archive reads transactions from
+a backup archive written by 'go-monzo backup' instead.
+
+With --all-accounts, the transactions of every open account are fetched and
+merged in date order, with the net amount and number of matching
+transactions for each account and currency. Declined transactions are not
+counted in the totals.
+
 You must be logged in before using this command. Use 'go-monzo login' first.
 You can obtain your account ID using the 'go-monzo accounts' command.`,
 	RunE: runTransactions,
@@ -72,27 +170,119 @@ func init() {
 	rootCmd.AddCommand(transactionsCmd)
 
 	transactionsCmd.Flags().StringVar(&txAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	transactionsCmd.Flags().StringVar(&txFilter, "filter", "", "Filter expression, e.g. 'amount < -5000 && merchant ~ \"Tesco\"'")
+	transactionsCmd.Flags().BoolVar(&txDeclined, "declined", false, "Only show declined transactions")
+	transactionsCmd.Flags().BoolVar(&txPending, "pending", false, "Only show pending transactions")
+	transactionsCmd.Flags().BoolVar(&txSettled, "settled", false, "Only show settled transactions")
+	transactionsCmd.Flags().BoolVar(&txOnline, "online", false, "Only show online transactions")
+	transactionsCmd.Flags().BoolVar(&txATM, "atm", false, "Only show ATM withdrawals")
+	transactionsCmd.Flags().StringVar(&txCurrency, "currency", "", "Only show transactions in this currency or local currency")
+	transactionsCmd.Flags().BoolVar(&txOffline, "offline", false, "Read transactions from the local cache instead of the API")
+
+	transactionsCmd.Flags().BoolVar(&txAll, "all-accounts", false, "Show transactions from all open accounts with totals")
+
+	transactionsCmd.MarkFlagsMutuallyExclusive("pending", "settled")
+	transactionsCmd.Flags().StringVar(&txArchive, "archive", "", "Read transactions from a backup archive instead of the API")
+
+	transactionsCmd.MarkFlagsMutuallyExclusive("offline", "all-accounts", "archive")
+
+	_ = transactionsCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+	_ = transactionsCmd.RegisterFlagCompletionFunc("currency", completeCurrencies)
 }
 
 func runTransactions(cmd *cobra.Command, args []string) error {
-	if txAccountID == "" {
+	if txAccountID == "" && !txAll {
 		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
 	}
 
+	filters, err := transactionFlagFilters()
+	if err != nil {
+		return err
+	}
+
+	if txAll {
+		return runAllAccountsTransactions(cmd.Context(), filters)
+	}
+
+	var transactions *TransactionsResponse
+	if txArchive != "" {
+		backup, err := readBackup(txArchive)
+		if err != nil {
+			return err
+		}
+		transactions = &TransactionsResponse{Transactions: backup.archiveTransactions(txAccountID)}
+	} else if txOffline {
+		transactions, err = loadTransactionCache(txAccountID)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Load the stored token
+		token, err := loadToken(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+		}
+
+		// Fetch transactions from the API
+		transactions, err = fetchTransactions(cmd.Context(), token.AccessToken, txAccountID, time.Time{}, time.Time{})
+		if err != nil {
+			return fmt.Errorf("failed to fetch transactions: %w", err)
+		}
+
+		if err := updateTransactionCache(txAccountID, transactions.Transactions); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to update transaction cache: %v\n", err)
+		}
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	transactions = &TransactionsResponse{Transactions: filterTransactions(transactions.Transactions, filters...)}
+
+	// Output as JSON
+	output, err := json.MarshalIndent(transactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal transactions: %w", err)
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
+
+// runAllAccountsTransactions outputs the transactions of every open account
+func runAllAccountsTransactions(ctx context.Context, filters []transactionFilter) error {
 	// Load the stored token
-	token, err := loadToken()
+	token, err := loadToken(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
 	}
 
-	// Fetch transactions from the API
-	transactions, err := fetchTransactions(token.AccessToken, txAccountID)
+	accounts, err := openAccounts(ctx, token.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	perAccount := make([][]Transaction, len(accounts))
+	err = forEachAccount(ctx, accounts, func(ctx context.Context, i int, account Account) error {
+		transactions, err := fetchTransactions(ctx, token.AccessToken, account.ID, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+		perAccount[i] = transactions.Transactions
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to fetch transactions: %w", err)
 	}
 
+	response, err := aggregateTransactions(accounts, perAccount, filters)
+	if err != nil {
+		return err
+	}
+
 	// Output as JSON
-	output, err := json.MarshalIndent(transactions, "", "  ")
+	output, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal transactions: %w", err)
 	}
@@ -101,11 +291,129 @@ func runTransactions(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func fetchTransactions(accessToken, accountID string) (*TransactionsResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+// aggregateTransactions caches, categorises and filters the transactions of
+// each account, then merges them in date order and totals them by currency
+func aggregateTransactions(accounts []Account, perAccount [][]Transaction, filters []transactionFilter) (*AllAccountsTransactionsResponse, error) {
+	response := &AllAccountsTransactionsResponse{Transactions: []Transaction{}}
+	grandTotals := make(map[string]*CurrencyTotal)
+
+	for i, account := range accounts {
+		transactions := perAccount[i]
+		if err := updateTransactionCache(account.ID, transactions); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to update transaction cache: %v\n", err)
+		}
+
+		if err := categorise(transactions); err != nil {
+			return nil, err
+		}
+
+		totals := make(map[string]*CurrencyTotal)
+		for _, tx := range filterTransactions(transactions, filters...) {
+			tx.AccountID = account.ID
+			response.Transactions = append(response.Transactions, tx)
+
+			if tx.IsDeclined() {
+				continue
+			}
+			addCurrencyTotal(totals, tx.Currency, tx.Amount)
+			addCurrencyTotal(grandTotals, tx.Currency, tx.Amount)
+		}
+
+		response.Accounts = append(response.Accounts, AccountTransactionTotals{
+			AccountID:   account.ID,
+			AccountName: account.Name(),
+			AccountType: account.Type,
+			Totals:      sortedCurrencyTotals(totals),
+		})
+	}
+
+	sort.SliceStable(response.Transactions, func(i, j int) bool {
+		return response.Transactions[i].CreatedTime().Before(response.Transactions[j].CreatedTime())
+	})
+	response.Totals = sortedCurrencyTotals(grandTotals)
+
+	return response, nil
+}
+
+// transactionFlagFilters builds the filters selected by the command line flags
+func transactionFlagFilters() ([]transactionFilter, error) {
+	var filters []transactionFilter
+
+	if txFilter != "" {
+		filter, err := compileFilter(txFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --filter expression: %w", err)
+		}
+		filters = append(filters, filter)
+	}
+
+	if txDeclined {
+		filters = append(filters, Transaction.IsDeclined)
+	}
+	if txPending {
+		filters = append(filters, Transaction.IsPending)
+	}
+	if txSettled {
+		filters = append(filters, func(tx Transaction) bool { return !tx.IsDeclined() && !tx.IsPending() })
+	}
+	if txOnline {
+		filters = append(filters, func(tx Transaction) bool { return tx.Merchant != nil && tx.Merchant.Online })
+	}
+	if txATM {
+		filters = append(filters, func(tx Transaction) bool { return tx.Merchant != nil && tx.Merchant.ATM })
+	}
+	if txCurrency != "" {
+		filters = append(filters, func(tx Transaction) bool {
+			return strings.EqualFold(tx.Currency, txCurrency) || strings.EqualFold(tx.LocalCurrency, txCurrency)
+		})
+	}
+
+	return filters, nil
+}
+
+// transactionsPageSize is the largest number of transactions the API returns
+// in one page
+const transactionsPageSize = 100
+
+// fetchTransactions retrieves transactions for an account, a page at a time.
+// A zero since or before leaves that end of the time range unbounded.
+func fetchTransactions(ctx context.Context, accessToken, accountID string, since, before time.Time) (*TransactionsResponse, error) {
+	// The first page starts at since, and each later page after the last
+	// transaction of the one before
+	sinceParam := ""
+	if !since.IsZero() {
+		sinceParam = since.UTC().Format(time.RFC3339)
+	}
+
+	all := &TransactionsResponse{Transactions: []Transaction{}}
+	for {
+		page, err := fetchTransactionsPage(ctx, accessToken, accountID, sinceParam, before, transactionsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all.Transactions = append(all.Transactions, page.Transactions...)
+
+		if len(page.Transactions) < transactionsPageSize {
+			return all, nil
+		}
+		sinceParam = page.Transactions[len(page.Transactions)-1].ID
+	}
+}
+
+// fetchTransactionsPage retrieves up to limit transactions for an account
+// created before before, if set. since is either a time or the ID of the
+// transaction to list after; if empty the page starts from the oldest.
+func fetchTransactionsPage(ctx context.Context, accessToken, accountID, since string, before time.Time, limit int) (*TransactionsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
 	defer cancel()
 
-	reqURL := fmt.Sprintf("%s/transactions?expand[]=merchant&account_id=%s", monzoAPIBaseURL, url.QueryEscape(accountID))
+	reqURL := fmt.Sprintf("%s/transactions?expand[]=merchant&account_id=%s&limit=%d", apiBaseURL, url.QueryEscape(accountID), limit)
+	if since != "" {
+		reqURL += "&since=" + url.QueryEscape(since)
+	}
+	if !before.IsZero() {
+		reqURL += "&before=" + url.QueryEscape(before.UTC().Format(time.RFC3339))
+	}
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -113,7 +421,7 @@ func fetchTransactions(accessToken, accountID string) (*TransactionsResponse, er
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
+	client := newHTTPClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -134,3 +442,127 @@ func fetchTransactions(accessToken, accountID string) (*TransactionsResponse, er
 
 	return &transactions, nil
 }
+
+// CreatedTime returns the time the transaction was created, or the zero time
+// if the timestamp can't be parsed
+func (t Transaction) CreatedTime() time.Time {
+	created, err := time.Parse(time.RFC3339Nano, t.Created)
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// IsDeclined reports whether the transaction was declined
+func (t Transaction) IsDeclined() bool {
+	return t.DeclineReason != ""
+}
+
+// IsPending reports whether the transaction has not yet settled. Declined
+// transactions never settle and aren't pending.
+func (t Transaction) IsPending() bool {
+	return !t.IsDeclined() && (t.AmountIsPending || t.Settled == "")
+}
+
+// IsPotTransfer reports whether the transaction moves money to or from a pot
+func (t Transaction) IsPotTransfer() bool {
+	return t.Metadata["pot_id"] != "" || strings.HasPrefix(t.Description, "pot_")
+}
+
+// IsSpending reports whether the transaction counts as outgoing spending:
+// a successful debit that Monzo includes in spending and isn't a pot transfer
+func (t Transaction) IsSpending() bool {
+	return t.Amount < 0 && t.IncludeInSpending && !t.IsDeclined() && !t.IsPotTransfer()
+}
+
+// IsCardPayment reports whether the transaction was made with the card, rather
+// than by direct debit, standing order or bank transfer
+func (t Transaction) IsCardPayment() bool {
+	return t.Scheme == "mastercard"
+}
+
+// MerchantName returns the merchant name, falling back to the description
+// for transactions without an expanded merchant
+func (t Transaction) MerchantName() string {
+	if t.Merchant != nil && t.Merchant.Name != "" {
+		return t.Merchant.Name
+	}
+	return t.Description
+}
+
+// fetchTransaction retrieves a single transaction by ID
+func fetchTransaction(ctx context.Context, accessToken, transactionID string) (*Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	reqURL := fmt.Sprintf("%s/transactions/%s?expand[]=merchant", apiBaseURL, url.PathEscape(transactionID))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var transaction TransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&transaction); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &transaction.Transaction, nil
+}
+
+// annotateTransaction sets metadata keys on a transaction. Setting a key to an
+// empty string removes it.
+func annotateTransaction(ctx context.Context, accessToken, transactionID string, metadata map[string]string) (*Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	data := url.Values{}
+	for key, value := range metadata {
+		data.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+
+	reqURL := fmt.Sprintf("%s/transactions/%s", apiBaseURL, url.PathEscape(transactionID))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", reqURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := newHTTPClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Error from ReadAll is intentionally ignored as we're in an error path
+		// and want to include whatever body content we can read in the error message
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var transaction TransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&transaction); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &transaction.Transaction, nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vibe-chung/go-monzo/monzotest"
+)
+
+func TestFetchTransactionsFollowsPages(t *testing.T) {
+	mock := useMockAPI(t)
+
+	now := time.Now()
+	since, before := now.AddDate(0, -3, 0), now.AddDate(0, 0, -7)
+
+	expected := 0
+	for _, tx := range mock.Transactions {
+		created, _ := time.Parse(time.RFC3339Nano, tx.Created)
+		if tx.AccountID == monzotest.PersonalAccountID && !created.Before(since) && created.Before(before) {
+			expected++
+		}
+	}
+	if expected <= transactionsPageSize {
+		t.Fatalf("Expected the window to span more than one page, got %d transactions", expected)
+	}
+
+	transactions, err := fetchTransactions(context.Background(), monzotest.AccessToken, monzotest.PersonalAccountID, since, before)
+	if err != nil {
+		t.Fatalf("Failed to fetch transactions: %v", err)
+	}
+	if len(transactions.Transactions) != expected {
+		t.Errorf("Expected all %d transactions in the window, got %d", expected, len(transactions.Transactions))
+	}
+	for _, tx := range transactions.Transactions {
+		if created := tx.CreatedTime(); created.Before(since) || !created.Before(before) {
+			t.Errorf("Transaction %s created at %s is outside the window", tx.ID, tx.Created)
+		}
+	}
+}
@@ -0,0 +1,722 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var tuiAccountID string
+
+// tuiMode is what keystrokes currently act on
+type tuiMode int
+
+const (
+	tuiModeList   tuiMode = iota // Browsing transactions
+	tuiModeSearch                // Typing a search query
+	tuiModeNotes                 // Editing the notes of a transaction
+	tuiModePot                   // Choosing a pot to move money into
+	tuiModeAmount                // Typing the amount to move into a pot
+)
+
+const tuiHelp = "↑/↓ move  tab account  / search  enter details  n notes  p pot  r refresh  q quit"
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Interactive dashboard of accounts, pots and transactions",
+	Long: `Open a full-screen dashboard showing your accounts, balances, pots and a
+scrollable list of transactions.
+
+Keys:
+  ↑/↓ or j/k        Move through transactions (PgUp/PgDn, g/G to jump)
+  tab               Switch to the next account
+  /                 Search by merchant, description, category or notes
+  enter             Show or hide merchant details for a transaction
+  n                 Edit the notes on a transaction
+  p                 Move money into a pot
+  r                 Refresh
+  esc               Cancel, or clear the search
+  q                 Quit
+
+The dashboard starts on the account given by --account-id, or the first open
+account. Categories reflect the local rules in ~/.go-monzo/rules.json.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().StringVar(&tuiAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID to show first (or set MONZO_ACCOUNT_ID)")
+
+	_ = tuiCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(stdout) {
+		return fmt.Errorf("the dashboard must be run in a terminal")
+	}
+
+	// Load the stored token
+	token, err := loadToken(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	ui := newTUI(cmd.Context(), &apiClient{accessToken: token.AccessToken}, os.Stdin, os.Stdout, func() (int, int) {
+		width, height, err := term.GetSize(stdout)
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	})
+
+	if err := ui.load(tuiAccountID); err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(stdin, state) }()
+
+	// Use the alternate screen so the shell is left as it was
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	return ui.run()
+}
+
+// tui is the state of the dashboard. It reads keys from in and draws to out,
+// so it can be driven by a simulated terminal in tests.
+type tui struct {
+	ctx    context.Context
+	client monzoClient
+	in     *bufio.Reader
+	out    io.Writer
+	size   func() (width, height int)
+
+	accounts     []Account
+	account      int
+	balance      *BalanceResponse
+	pots         []Pot
+	transactions []Transaction // Newest first
+	visible      []Transaction // Transactions matching the search
+
+	mode       tuiMode
+	query      string
+	input      string
+	cursor     int
+	offset     int
+	potCursor  int
+	showDetail bool
+	status     string
+	quit       bool
+}
+
+func newTUI(ctx context.Context, client monzoClient, in io.Reader, out io.Writer, size func() (int, int)) *tui {
+	return &tui{
+		ctx:    ctx,
+		client: client,
+		in:     bufio.NewReader(in),
+		out:    out,
+		size:   size,
+	}
+}
+
+// load fetches the open accounts and selects accountID, or the first account
+func (t *tui) load(accountID string) error {
+	accounts, err := t.client.Accounts(t.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		if !account.Closed {
+			t.accounts = append(t.accounts, account)
+		}
+	}
+	if len(t.accounts) == 0 {
+		return fmt.Errorf("no open accounts found")
+	}
+
+	for i, account := range t.accounts {
+		if account.ID == accountID {
+			t.account = i
+		}
+	}
+
+	return t.loadAccount()
+}
+
+// loadAccount fetches the balance, pots and transactions of the selected account
+func (t *tui) loadAccount() error {
+	account := t.accounts[t.account]
+
+	balance, err := t.client.Balance(t.ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	pots, err := t.client.Pots(t.ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pots: %w", err)
+	}
+
+	transactions, err := t.client.Transactions(t.ctx, account.ID, time.Time{}, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := updateTransactionCache(account.ID, transactions); err != nil {
+		t.status = fmt.Sprintf("Warning: failed to update transaction cache: %v", err)
+	}
+
+	if err := categorise(transactions); err != nil {
+		return err
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].CreatedTime().After(transactions[j].CreatedTime())
+	})
+
+	t.balance = balance
+	t.pots = openPots(pots)
+	t.transactions = transactions
+	t.applySearch()
+	return nil
+}
+
+// run draws the dashboard and handles keys until the user quits, input ends
+// or the context is cancelled
+func (t *tui) run() error {
+	for !t.quit && t.ctx.Err() == nil {
+		t.render()
+
+		key, err := readKey(t.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		t.handleKey(key)
+	}
+	return nil
+}
+
+func (t *tui) handleKey(key string) {
+	if key == "ctrl+c" {
+		t.quit = true
+		return
+	}
+
+	switch t.mode {
+	case tuiModeList:
+		t.handleListKey(key)
+	case tuiModeSearch:
+		if t.editInput(key) {
+			t.query = t.input
+			t.applySearch()
+			return
+		}
+		switch key {
+		case "enter":
+			t.mode = tuiModeList
+		case "esc":
+			t.query = ""
+			t.applySearch()
+			t.mode = tuiModeList
+		}
+	case tuiModeNotes:
+		if t.editInput(key) {
+			return
+		}
+		switch key {
+		case "enter":
+			t.saveNotes()
+			t.mode = tuiModeList
+		case "esc":
+			t.mode = tuiModeList
+		}
+	case tuiModePot:
+		switch key {
+		case "up", "k":
+			t.potCursor = max(t.potCursor-1, 0)
+		case "down", "j":
+			t.potCursor = min(t.potCursor+1, len(t.pots)-1)
+		case "enter":
+			t.input = ""
+			t.mode = tuiModeAmount
+		case "esc":
+			t.mode = tuiModeList
+		}
+	case tuiModeAmount:
+		if t.editInput(key) {
+			return
+		}
+		switch key {
+		case "enter":
+			t.depositIntoPot()
+			t.mode = tuiModeList
+		case "esc":
+			t.mode = tuiModeList
+		}
+	}
+}
+
+func (t *tui) handleListKey(key string) {
+	t.status = ""
+
+	switch key {
+	case "q":
+		t.quit = true
+	case "up", "k":
+		t.moveCursor(-1)
+	case "down", "j":
+		t.moveCursor(1)
+	case "pgup":
+		t.moveCursor(-t.listHeight())
+	case "pgdn":
+		t.moveCursor(t.listHeight())
+	case "home", "g":
+		t.moveCursor(-len(t.visible))
+	case "end", "G":
+		t.moveCursor(len(t.visible))
+	case "tab":
+		t.account = (t.account + 1) % len(t.accounts)
+		t.cursor, t.offset = 0, 0
+		t.query = ""
+		t.refresh()
+	case "r":
+		t.refresh()
+	case "enter":
+		t.showDetail = !t.showDetail
+	case "esc":
+		t.showDetail = false
+		t.query = ""
+		t.applySearch()
+	case "/":
+		t.input = t.query
+		t.mode = tuiModeSearch
+	case "n":
+		if tx := t.selected(); tx != nil {
+			t.input = tx.Notes
+			t.mode = tuiModeNotes
+		}
+	case "p":
+		if len(t.pots) == 0 {
+			t.status = "This account has no pots"
+			return
+		}
+		t.potCursor = 0
+		t.mode = tuiModePot
+	}
+}
+
+// editInput applies a key to the text being typed, reporting whether the key
+// was consumed
+func (t *tui) editInput(key string) bool {
+	switch {
+	case key == "backspace":
+		if t.input != "" {
+			_, size := utf8.DecodeLastRuneInString(t.input)
+			t.input = t.input[:len(t.input)-size]
+		}
+		return true
+	case utf8.RuneCountInString(key) == 1:
+		t.input += key
+		return true
+	}
+	return false
+}
+
+func (t *tui) refresh() {
+	if err := t.loadAccount(); err != nil {
+		t.status = err.Error()
+	}
+}
+
+func (t *tui) saveNotes() {
+	tx := t.selected()
+	if tx == nil {
+		return
+	}
+
+	updated, err := t.client.Annotate(t.ctx, tx.ID, map[string]string{"notes": t.input})
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to save notes: %v", err)
+		return
+	}
+
+	// Keep the locally categorised copy, with the notes the API now holds
+	notes := t.input
+	if updated != nil {
+		notes = updated.Notes
+	}
+	for i := range t.transactions {
+		if t.transactions[i].ID == tx.ID {
+			t.transactions[i].Notes = notes
+		}
+	}
+	t.applySearch()
+	t.status = "Notes saved"
+}
+
+func (t *tui) depositIntoPot() {
+	pot := t.pots[t.potCursor]
+	account := t.accounts[t.account]
+
+	amount, err := ParseMoney(t.input, pot.Currency)
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+	if amount.Currency != pot.Currency {
+		t.status = fmt.Sprintf("%s is in %s, not %s", pot.Name, pot.Currency, amount.Currency)
+		return
+	}
+	if amount.Amount <= 0 {
+		t.status = "The amount must be greater than zero"
+		return
+	}
+
+	dedupeID, err := newDedupeID()
+	if err != nil {
+		t.status = fmt.Sprintf("Failed to generate dedupe ID: %v", err)
+		return
+	}
+
+	if _, err := t.client.Deposit(t.ctx, pot.ID, account.ID, amount.Amount, dedupeID); err != nil {
+		t.status = fmt.Sprintf("Failed to move money into %s: %v", pot.Name, err)
+		return
+	}
+
+	t.refresh()
+	if t.status == "" {
+		t.status = fmt.Sprintf("Moved %s into %s", amount.String(), pot.Name)
+	}
+}
+
+// applySearch updates the visible transactions to those matching the query
+func (t *tui) applySearch() {
+	query := strings.ToLower(t.query)
+
+	t.visible = t.visible[:0]
+	for _, tx := range t.transactions {
+		if query == "" || transactionMatchesSearch(tx, query) {
+			t.visible = append(t.visible, tx)
+		}
+	}
+
+	t.moveCursor(0)
+}
+
+// transactionMatchesSearch reports whether a lower case query appears in the
+// transaction's merchant, description, category or notes
+func transactionMatchesSearch(tx Transaction, query string) bool {
+	for _, field := range []string{tx.MerchantName(), tx.Description, tx.Category, tx.Notes} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tui) selected() *Transaction {
+	if t.cursor < 0 || t.cursor >= len(t.visible) {
+		return nil
+	}
+	return &t.visible[t.cursor]
+}
+
+// moveCursor moves the selection by delta, scrolling to keep it visible
+func (t *tui) moveCursor(delta int) {
+	t.cursor = max(min(t.cursor+delta, len(t.visible)-1), 0)
+
+	height := t.listHeight()
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+height {
+		t.offset = t.cursor - height + 1
+	}
+}
+
+// detailHeight is the number of lines used by the detail or pot pane
+func (t *tui) detailHeight() int {
+	switch {
+	case t.mode == tuiModePot || t.mode == tuiModeAmount:
+		return len(t.pots) + 2
+	case t.showDetail:
+		return 10
+	}
+	return 0
+}
+
+// listHeight is the number of transactions that fit on screen
+func (t *tui) listHeight() int {
+	_, height := t.size()
+	// Header, pots, blank line, column headings, blank line and footer
+	return max(height-6-t.detailHeight(), 1)
+}
+
+func (t *tui) render() {
+	width, height := t.size()
+	lines := make([]string, 0, height)
+
+	account := t.accounts[t.account]
+	header := fmt.Sprintf("%s %s  [%d/%d]", account.Type, account.ID, t.account+1, len(t.accounts))
+	if t.balance != nil {
+		header += fmt.Sprintf("  Balance %s  Spent today %s",
+			NewMoney(t.balance.Balance, t.balance.Currency).String(),
+			NewMoney(-t.balance.SpendToday, t.balance.Currency).String())
+	}
+	lines = append(lines, header)
+
+	pots := make([]string, 0, len(t.pots))
+	for _, pot := range t.pots {
+		pots = append(pots, fmt.Sprintf("%s %s", pot.Name, NewMoney(pot.Balance, pot.Currency).String()))
+	}
+	if len(pots) == 0 {
+		pots = append(pots, "none")
+	}
+	lines = append(lines, "Pots: "+strings.Join(pots, "  "), "")
+
+	// Date, amount and category columns are fixed; the merchant takes the rest
+	merchantWidth := max(width-2-10-2-12-2-16, 10)
+	lines = append(lines, fmt.Sprintf("  %s  %s  %s  %s", column("DATE", 10), column("MERCHANT", merchantWidth), leftPad("AMOUNT", 12), "CATEGORY"))
+
+	listHeight := t.listHeight()
+	for i := t.offset; i < t.offset+listHeight; i++ {
+		if i >= len(t.visible) {
+			lines = append(lines, "")
+			continue
+		}
+		tx := t.visible[i]
+		marker := "  "
+		if i == t.cursor {
+			marker = "> "
+		}
+		lines = append(lines, marker+fmt.Sprintf("%s  %s  %s  %s",
+			column(tx.CreatedTime().Format(dateLayout), 10),
+			column(tx.MerchantName(), merchantWidth),
+			leftPad(NewMoney(tx.Amount, tx.Currency).String(), 12),
+			tx.Category))
+	}
+
+	switch {
+	case t.mode == tuiModePot || t.mode == tuiModeAmount:
+		lines = append(lines, strings.Repeat("─", width), "Move money into a pot:")
+		for i, pot := range t.pots {
+			marker := "  "
+			if i == t.potCursor {
+				marker = "> "
+			}
+			lines = append(lines, marker+pot.Name+"  "+NewMoney(pot.Balance, pot.Currency).String())
+		}
+	case t.showDetail:
+		lines = append(lines, t.detailLines(width)...)
+	}
+
+	lines = append(lines, "", t.footer())
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for i, line := range lines {
+		if i >= height {
+			break
+		}
+		if i > 0 {
+			// The terminal is in raw mode, so a carriage return is needed too
+			b.WriteString("\r\n")
+		}
+		b.WriteString(truncate(line, width))
+	}
+	_, _ = io.WriteString(t.out, b.String())
+}
+
+// detailLines describes the selected transaction and its merchant
+func (t *tui) detailLines(width int) []string {
+	lines := []string{strings.Repeat("─", width)}
+
+	tx := t.selected()
+	if tx == nil {
+		return append(lines, "No transaction selected")
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("%s  %s  %s", tx.ID, tx.CreatedTime().Format("2006-01-02 15:04"), NewMoney(tx.Amount, tx.Currency).String()),
+		"Description: "+tx.Description,
+		"Notes: "+tx.Notes)
+
+	if tx.IsDeclined() {
+		lines = append(lines, "Declined: "+tx.DeclineReason)
+	}
+
+	m := tx.Merchant
+	if m == nil {
+		return append(lines, "No merchant details")
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("Merchant: %s (%s)", strings.Join(nonEmpty(m.Emoji, m.Name), " "), m.Category),
+		fmt.Sprintf("Merchant ID: %s  Group: %s", m.ID, m.GroupID))
+	if m.Address != nil {
+		lines = append(lines, "Address: "+strings.Join(nonEmpty(m.Address.Address, m.Address.City, m.Address.Postcode, m.Address.Country), ", "))
+	}
+
+	var flags []string
+	if m.Online {
+		flags = append(flags, "online")
+	}
+	if m.ATM {
+		flags = append(flags, "ATM")
+	}
+	if len(tx.Tags) > 0 {
+		flags = append(flags, "tags: "+strings.Join(tx.Tags, ", "))
+	}
+	if len(flags) > 0 {
+		lines = append(lines, strings.Join(flags, "  "))
+	}
+
+	return lines
+}
+
+func (t *tui) footer() string {
+	switch t.mode {
+	case tuiModeSearch:
+		return "Search: " + t.input + "█"
+	case tuiModeNotes:
+		return "Notes: " + t.input + "█"
+	case tuiModePot:
+		return "↑/↓ choose pot  enter select  esc cancel"
+	case tuiModeAmount:
+		return fmt.Sprintf("Amount to move into %s: %s█", t.pots[t.potCursor].Name, t.input)
+	}
+
+	status := t.status
+	if t.query != "" {
+		status = strings.TrimSpace(fmt.Sprintf("%s  [search: %s, %d of %d]", status, t.query, len(t.visible), len(t.transactions)))
+	}
+	if status != "" {
+		return status
+	}
+	return tuiHelp
+}
+
+// readKey reads a key press, naming special keys such as "up" and "enter"
+func readKey(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case 0x1b:
+		// A lone escape, unless the rest of a control sequence has arrived with it
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		if next, err := r.Peek(1); err != nil || (next[0] != '[' && next[0] != 'O') {
+			return "esc", nil
+		}
+		_, _ = r.ReadByte()
+
+		var seq []byte
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return "esc", nil
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+
+		switch string(seq) {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "H", "1~":
+			return "home", nil
+		case "F", "4~":
+			return "end", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdn", nil
+		}
+		return "", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case '\t':
+		return "tab", nil
+	case 0x03:
+		return "ctrl+c", nil
+	}
+
+	if b < 0x20 {
+		return "", nil
+	}
+
+	if err := r.UnreadByte(); err != nil {
+		return "", err
+	}
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	return string(c), nil
+}
+
+// column pads or truncates s to exactly width characters
+func column(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// leftPad right-aligns s in width characters
+func leftPad(s string, width int) string {
+	return strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0)) + s
+}
+
+// truncate shortens s to at most width characters
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if width <= 1 {
+		return string(runes[:max(width, 0)])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// nonEmpty returns the values that aren't empty
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
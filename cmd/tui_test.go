@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClient is an in-memory monzoClient
+type fakeClient struct {
+	accounts     []Account
+	pots         map[string][]Pot
+	transactions map[string][]Transaction
+
+	annotated map[string]map[string]string
+	deposits  []int64
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		accounts: []Account{
+			{ID: "acc_1", Type: "uk_retail"},
+			{ID: "acc_2", Type: "uk_retail_joint"},
+			{ID: "acc_old", Type: "uk_retail", Closed: true},
+		},
+		pots: map[string][]Pot{
+			"acc_1": {
+				{ID: "pot_1", Name: "Holiday", Balance: 10000, Currency: "GBP"},
+				{ID: "pot_2", Name: "Old pot", Currency: "GBP", Deleted: true},
+			},
+		},
+		transactions: map[string][]Transaction{
+			"acc_1": {
+				{ID: "tx_1", Created: "2024-04-01T09:00:00Z", Description: "TESCO", Amount: -2500, Currency: "GBP", Category: "groceries",
+					Merchant: &Merchant{ID: "merch_1", GroupID: "grp_1", Name: "Tesco", Category: "groceries", Address: &MerchantAddress{City: "London"}}},
+				{ID: "tx_2", Created: "2024-04-02T12:00:00Z", Description: "PRET", Amount: -450, Currency: "GBP", Category: "eating_out",
+					Merchant: &Merchant{ID: "merch_2", Name: "Pret A Manger", Category: "eating_out"}},
+			},
+			"acc_2": {
+				{ID: "tx_3", Created: "2024-04-03T12:00:00Z", Description: "Rent", Amount: -100000, Currency: "GBP", Category: "bills"},
+			},
+		},
+		annotated: make(map[string]map[string]string),
+	}
+}
+
+func (c *fakeClient) Accounts(ctx context.Context) ([]Account, error) {
+	return c.accounts, nil
+}
+
+func (c *fakeClient) Balance(ctx context.Context, accountID string) (*BalanceResponse, error) {
+	return &BalanceResponse{Balance: 123456, Currency: "GBP", SpendToday: -450}, nil
+}
+
+func (c *fakeClient) Pots(ctx context.Context, accountID string) ([]Pot, error) {
+	return c.pots[accountID], nil
+}
+
+func (c *fakeClient) Transactions(ctx context.Context, accountID string, since, before time.Time) ([]Transaction, error) {
+	return append([]Transaction(nil), c.transactions[accountID]...), nil
+}
+
+func (c *fakeClient) Annotate(ctx context.Context, transactionID string, metadata map[string]string) (*Transaction, error) {
+	c.annotated[transactionID] = metadata
+	return &Transaction{ID: transactionID, Notes: metadata["notes"]}, nil
+}
+
+func (c *fakeClient) Deposit(ctx context.Context, potID, sourceAccountID string, amount int64, dedupeID string) (*Pot, error) {
+	c.deposits = append(c.deposits, amount)
+	return &Pot{ID: potID}, nil
+}
+
+// runTUITest drives the dashboard with the given keystrokes and returns the
+// last screen drawn
+func runTUITest(t *testing.T, client *fakeClient, keys string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	var out bytes.Buffer
+	ui := newTUI(context.Background(), client, strings.NewReader(keys), &out, func() (int, int) { return 100, 30 })
+	if err := ui.load("acc_1"); err != nil {
+		t.Fatalf("Failed to load dashboard: %v", err)
+	}
+	if err := ui.run(); err != nil {
+		t.Fatalf("Dashboard failed: %v", err)
+	}
+
+	frames := strings.Split(out.String(), "\x1b[2J")
+	return frames[len(frames)-1]
+}
+
+func TestTUIShowsAccountAndTransactions(t *testing.T) {
+	screen := runTUITest(t, newFakeClient(), "")
+
+	for _, want := range []string{"acc_1  [1/2]", "Balance £1,234.56", "Holiday £100.00", "> 2024-04-02  Pret A Manger", "Tesco"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected screen to contain %q, got:\n%s", want, screen)
+		}
+	}
+	if strings.Contains(screen, "Old pot") {
+		t.Errorf("Deleted pot should not be shown")
+	}
+}
+
+func TestTUISearchAndDetail(t *testing.T) {
+	screen := runTUITest(t, newFakeClient(), "/tesco\r\r")
+
+	if strings.Contains(screen, "Pret") {
+		t.Errorf("Expected search to hide Pret, got:\n%s", screen)
+	}
+	for _, want := range []string{"Merchant: Tesco (groceries)", "Group: grp_1", "Address: London", "search: tesco, 1 of 2"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected screen to contain %q, got:\n%s", want, screen)
+		}
+	}
+}
+
+func TestTUISwitchAccount(t *testing.T) {
+	screen := runTUITest(t, newFakeClient(), "\t")
+
+	if !strings.Contains(screen, "acc_2  [2/2]") || !strings.Contains(screen, "Rent") {
+		t.Errorf("Expected joint account, got:\n%s", screen)
+	}
+}
+
+func TestTUIAnnotate(t *testing.T) {
+	client := newFakeClient()
+	screen := runTUITest(t, client, "jnweekly shop\r")
+
+	if got := client.annotated["tx_1"]["notes"]; got != "weekly shop" {
+		t.Errorf("Expected notes to be saved on tx_1, got %q", got)
+	}
+	if !strings.Contains(screen, "Notes saved") {
+		t.Errorf("Expected confirmation, got:\n%s", screen)
+	}
+}
+
+func TestTUIDepositIntoPot(t *testing.T) {
+	client := newFakeClient()
+	screen := runTUITest(t, client, "p\r12.50\r")
+
+	if len(client.deposits) != 1 || client.deposits[0] != 1250 {
+		t.Errorf("Expected a deposit of 1250, got %v", client.deposits)
+	}
+	if !strings.Contains(screen, "Moved £12.50 into Holiday") {
+		t.Errorf("Expected confirmation, got:\n%s", screen)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	ui := newTUI(context.Background(), nil, strings.NewReader("\x1b[A\x1b[6~x£\r\x7f"), nil, nil)
+
+	var keys []string
+	for {
+		key, err := readKey(ui.in)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+
+	want := []string{"up", "pgdn", "x", "£", "enter", "backspace"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected keys %v, got %v", want, keys)
+	}
+}
@@ -0,0 +1,521 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	alertsFile      = "alerts.json"
+	alertsStateFile = "alerts-state.json"
+
+	// alertRetention is how long sent transaction alerts are remembered
+	alertRetention = 90 * 24 * time.Hour
+)
+
+var (
+	watchAccountID string
+	watchInterval  time.Duration
+	watchBelow     string
+	watchOver      string
+	watchListen    string
+	watchSecret    string
+	watchOnce      bool
+)
+
+// AlertRule represents a condition to be alerted about
+type AlertRule struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`               // balance_below or transaction_over
+	Amount   int64  `json:"amount"`             // Threshold in minor units
+	Currency string `json:"currency,omitempty"` // Only apply to amounts in this currency
+	Filter   string `json:"filter,omitempty"`   // Filter expression transactions must also match
+
+	filter transactionFilter
+}
+
+// AlertConfig represents the alert rules and notifiers stored in
+// ~/.go-monzo/alerts.json
+type AlertConfig struct {
+	Rules     []AlertRule `json:"rules"`
+	Notifiers []Notifier  `json:"notifiers"`
+}
+
+// AlertState records what has already been alerted on, so alerts aren't
+// repeated between polls or runs
+type AlertState struct {
+	Since map[string]time.Time `json:"since"` // Newest transaction seen per account
+	Sent  map[string]time.Time `json:"sent"`  // When each alert was sent, by key
+
+	// Failed holds alerts that no notifier delivered, to retry on the next
+	// delivery
+	Failed []Alert `json:"failed,omitempty"`
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Alert on low balances and large transactions",
+	Long: `Poll an account's balance and new transactions, and send alerts when the
+balance drops below a threshold or a transaction exceeds a limit.
+
+Rules and notifiers are read from ~/.go-monzo/alerts.json. Amounts are in minor
+units, and transaction rules can be narrowed with a filter expression using the
+same syntax as 'go-monzo transactions --filter':
+
+  {
+    "rules": [
+      {"name": "low balance", "type": "balance_below", "amount": 10000},
+      {"name": "big spend", "type": "transaction_over", "amount": 50000,
+       "filter": "category != \"bills\""}
+    ],
+    "notifiers": [
+      {"type": "stdout"},
+      {"type": "exec", "command": ["notify-send", "Monzo"]},
+      {"type": "webhook", "url": "https://example.com/hook"},
+      {"type": "feed", "image_url": "https://example.com/icon.png"}
+    ]
+  }
+
+--below and --over add rules without editing the file, e.g.
+'go-monzo watch --below £100 --over £500'. Alerts go to stdout when no
+notifiers are configured. Exec commands receive the alert as JSON on stdin and
+in MONZO_ALERT_* environment variables. Webhook notifiers receive the alert as
+a JSON POST, and feed notifiers add an item to the account's Monzo feed using
+the icon at image_url.
+
+Each alert is sent once: a transaction alert is never repeated, and a balance
+alert is repeated only after the balance has recovered above the threshold.
+An alert that no notifier could deliver is retried on the next poll.
+Only transactions made after the first run are considered.
+
+With --listen, transactions are also received from Monzo webhooks posted to
+that address, so large transactions are alerted on as soon as they happen.
+Monzo doesn't sign webhooks, so --webhook-secret (or MONZO_WEBHOOK_SECRET) is
+required and must be the path of the webhook URL: register it with Monzo for
+the account separately as e.g. https://example.com/SECRET. Webhooks posted to
+any other path, or for another account, are rejected.
+
+You must be logged in before using this command. Use 'go-monzo login' first.`,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVar(&watchAccountID, "account-id", os.Getenv("MONZO_ACCOUNT_ID"), "Monzo account ID (or set MONZO_ACCOUNT_ID)")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Minute, "Time between polls")
+	watchCmd.Flags().StringVar(&watchBelow, "below", "", "Alert when the balance drops below this amount, e.g. £100")
+	watchCmd.Flags().StringVar(&watchOver, "over", "", "Alert on transactions larger than this amount, e.g. £500")
+	watchCmd.Flags().StringVar(&watchListen, "listen", "", "Address to receive Monzo transaction webhooks on, e.g. :8080")
+	watchCmd.Flags().StringVar(&watchSecret, "webhook-secret", os.Getenv("MONZO_WEBHOOK_SECRET"), "Secret path webhooks must be posted to (or set MONZO_WEBHOOK_SECRET)")
+	watchCmd.Flags().BoolVar(&watchOnce, "once", false, "Poll once and exit")
+
+	_ = watchCmd.RegisterFlagCompletionFunc("account-id", completeAccountIDs)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchAccountID == "" {
+		return fmt.Errorf("account ID is required. Set via --account-id flag or MONZO_ACCOUNT_ID environment variable")
+	}
+
+	if watchInterval < time.Minute {
+		return fmt.Errorf("--interval must be at least 1m")
+	}
+
+	if watchListen != "" && watchSecret == "" {
+		return fmt.Errorf("--webhook-secret is required with --listen. Set via --webhook-secret flag or MONZO_WEBHOOK_SECRET environment variable")
+	}
+
+	config, err := loadAlertConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := addFlagAlertRules(config); err != nil {
+		return err
+	}
+
+	if len(config.Rules) == 0 {
+		return fmt.Errorf("no alert rules configured. Add rules to ~/.go-monzo/alerts.json or use --below and --over")
+	}
+
+	// Check the token up front so a missing login isn't reported on every poll
+	ctx := cmd.Context()
+	if _, err := loadToken(ctx); err != nil {
+		return fmt.Errorf("failed to load token: %w. Please run 'go-monzo login' first", err)
+	}
+
+	state, err := loadAlertState()
+	if err != nil {
+		return err
+	}
+
+	watcher := &alertWatcher{config: config, state: state}
+	if watcher.state.Since[watchAccountID].IsZero() {
+		watcher.state.Since[watchAccountID] = time.Now()
+	}
+
+	if watchListen != "" {
+		server := &http.Server{Addr: watchListen, Handler: watcher.webhookHandler(watchAccountID, watchSecret), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "Warning: webhook listener stopped: %v\n", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+	}
+
+	for {
+		if err := watcher.poll(ctx, watchAccountID); err != nil {
+			if watchOnce {
+				return err
+			}
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+
+		if watchOnce {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "Shutting down...")
+			return nil
+		case <-time.After(watchInterval):
+		}
+	}
+}
+
+// loadAlertConfig loads and validates ~/.go-monzo/alerts.json
+func loadAlertConfig() (*AlertConfig, error) {
+	var config AlertConfig
+	if err := loadStateFile(alertsFile, &config); err != nil {
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("alert %d", i+1)
+		}
+		if rule.Type != "balance_below" && rule.Type != "transaction_over" {
+			return nil, fmt.Errorf("alert %q has unknown type %q: must be balance_below or transaction_over", rule.Name, rule.Type)
+		}
+		if rule.Amount < 0 {
+			return nil, fmt.Errorf("alert %q has a negative amount", rule.Name)
+		}
+		if rule.Filter != "" {
+			if rule.Type != "transaction_over" {
+				return nil, fmt.Errorf("alert %q: filters only apply to transaction_over alerts", rule.Name)
+			}
+			filter, err := compileFilter(rule.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("alert %q has an invalid filter: %w", rule.Name, err)
+			}
+			rule.filter = filter
+		}
+	}
+
+	for _, notifier := range config.Notifiers {
+		if err := notifier.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Notifiers) == 0 {
+		config.Notifiers = []Notifier{{Type: "stdout"}}
+	}
+
+	return &config, nil
+}
+
+// addFlagAlertRules adds the rules given by --below and --over
+func addFlagAlertRules(config *AlertConfig) error {
+	if watchBelow != "" {
+		amount, err := ParseMoney(watchBelow, "GBP")
+		if err != nil {
+			return fmt.Errorf("invalid --below: %w", err)
+		}
+		config.Rules = append(config.Rules, AlertRule{
+			Name:     "balance below " + amount.String(),
+			Type:     "balance_below",
+			Amount:   amount.Amount,
+			Currency: amount.Currency,
+		})
+	}
+
+	if watchOver != "" {
+		amount, err := ParseMoney(watchOver, "GBP")
+		if err != nil {
+			return fmt.Errorf("invalid --over: %w", err)
+		}
+		if amount.Amount < 0 {
+			return fmt.Errorf("invalid --over: the amount must not be negative")
+		}
+		config.Rules = append(config.Rules, AlertRule{
+			Name:     "transaction over " + amount.String(),
+			Type:     "transaction_over",
+			Amount:   amount.Amount,
+			Currency: amount.Currency,
+		})
+	}
+
+	return nil
+}
+
+func loadAlertState() (*AlertState, error) {
+	var state AlertState
+	if err := loadStateFile(alertsStateFile, &state); err != nil {
+		return nil, fmt.Errorf("failed to load alert state: %w", err)
+	}
+	if state.Since == nil {
+		state.Since = make(map[string]time.Time)
+	}
+	if state.Sent == nil {
+		state.Sent = make(map[string]time.Time)
+	}
+	return &state, nil
+}
+
+// appliesTo reports whether the rule applies to amounts in a currency
+func (r AlertRule) appliesTo(currency string) bool {
+	return r.Currency == "" || r.Currency == currency
+}
+
+// alertWatcher evaluates alert rules, remembering what has been sent
+type alertWatcher struct {
+	config *AlertConfig
+	state  *AlertState
+	mu     sync.Mutex
+}
+
+// poll checks the balance and new transactions of an account and delivers any
+// alerts
+func (w *alertWatcher) poll(ctx context.Context, accountID string) error {
+	// Load the token on every poll so it is refreshed when it expires
+	token, err := loadToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load token: %w", err)
+	}
+
+	balance, err := fetchBalance(ctx, token.AccessToken, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance: %w", err)
+	}
+
+	w.mu.Lock()
+	since := w.state.Since[accountID]
+	w.mu.Unlock()
+
+	transactions, err := fetchTransactions(ctx, token.AccessToken, accountID, since, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	if err := categorise(transactions.Transactions); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	alerts := w.checkBalance(accountID, balance, now)
+	alerts = append(alerts, w.checkTransactions(accountID, transactions.Transactions, now)...)
+	w.advanceSince(accountID, transactions.Transactions)
+
+	return w.deliver(ctx, alerts, token.AccessToken, now)
+}
+
+// advanceSince records the newest polled transaction, so the next poll starts
+// from there. Only polled transactions are trusted to move it.
+func (w *alertWatcher) advanceSince(accountID string, transactions []Transaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, tx := range transactions {
+		if created := tx.CreatedTime(); created.After(w.state.Since[accountID]) {
+			w.state.Since[accountID] = created
+		}
+	}
+}
+
+// checkBalance returns the balance alerts that haven't already been sent
+func (w *alertWatcher) checkBalance(accountID string, balance *BalanceResponse, now time.Time) []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var alerts []Alert
+	for _, rule := range w.config.Rules {
+		if rule.Type != "balance_below" || !rule.appliesTo(balance.Currency) {
+			continue
+		}
+
+		key := rule.Name + ":" + accountID
+		if balance.Balance >= rule.Amount {
+			// Re-arm the alert once the balance has recovered
+			delete(w.state.Sent, key)
+			continue
+		}
+		if _, sent := w.state.Sent[key]; sent {
+			continue
+		}
+		w.state.Sent[key] = now
+
+		alerts = append(alerts, Alert{
+			Rule:      rule.Name,
+			Type:      rule.Type,
+			Message:   fmt.Sprintf("Balance is %s, below %s", NewMoney(balance.Balance, balance.Currency).String(), NewMoney(rule.Amount, balance.Currency).String()),
+			AccountID: accountID,
+			Amount:    balance.Balance,
+			Currency:  balance.Currency,
+			Time:      now,
+		})
+	}
+	return alerts
+}
+
+// checkTransactions returns the transaction alerts that haven't already been
+// sent
+func (w *alertWatcher) checkTransactions(accountID string, transactions []Transaction, now time.Time) []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var alerts []Alert
+	for _, tx := range transactions {
+		if tx.Amount >= 0 || tx.IsDeclined() || tx.IsPotTransfer() {
+			continue
+		}
+
+		for _, rule := range w.config.Rules {
+			if rule.Type != "transaction_over" || !rule.appliesTo(tx.Currency) || -tx.Amount <= rule.Amount {
+				continue
+			}
+			if rule.filter != nil && !rule.filter(tx) {
+				continue
+			}
+
+			key := rule.Name + ":" + tx.ID
+			if _, sent := w.state.Sent[key]; sent {
+				continue
+			}
+			w.state.Sent[key] = now
+
+			alerts = append(alerts, Alert{
+				Rule:          rule.Name,
+				Type:          rule.Type,
+				Message:       fmt.Sprintf("%s spent at %s", NewMoney(-tx.Amount, tx.Currency).String(), tx.MerchantName()),
+				AccountID:     accountID,
+				Amount:        tx.Amount,
+				Currency:      tx.Currency,
+				TransactionID: tx.ID,
+				Time:          now,
+			})
+		}
+	}
+	return alerts
+}
+
+// deliver sends alerts, and any that failed before, to every notifier and
+// saves the alert state. Failed deliveries are reported but don't stop the
+// others, and alerts no notifier delivered are kept to retry.
+func (w *alertWatcher) deliver(ctx context.Context, alerts []Alert, accessToken string, now time.Time) error {
+	w.mu.Lock()
+	alerts = append(w.state.Failed, alerts...)
+	w.state.Failed = nil
+	w.mu.Unlock()
+
+	var failed []Alert
+	for _, alert := range alerts {
+		delivered := false
+		for _, notifier := range w.config.Notifiers {
+			if err := notifier.notify(ctx, alert, accessToken); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to send %q alert via %s: %v\n", alert.Rule, notifier.Type, err)
+				continue
+			}
+			delivered = true
+		}
+		if !delivered && now.Sub(alert.Time) <= alertRetention {
+			failed = append(failed, alert)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.state.Failed = append(w.state.Failed, failed...)
+
+	for key, sent := range w.state.Sent {
+		if now.Sub(sent) > alertRetention {
+			delete(w.state.Sent, key)
+		}
+	}
+
+	if err := saveStateFile(alertsStateFile, w.state); err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// webhookHandler receives Monzo transaction.created webhooks posted to the
+// secret path and checks the account's transactions against the alert rules
+func (w *alertWatcher) webhookHandler(accountID, secret string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if secret == "" || subtle.ConstantTimeCompare([]byte(path), []byte(secret)) != 1 {
+			http.NotFound(rw, r)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var event struct {
+			Type string      `json:"type"`
+			Data Transaction `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(rw, "invalid webhook body", http.StatusBadRequest)
+			return
+		}
+
+		if event.Type != "transaction.created" {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		if event.Data.AccountID != accountID {
+			http.Error(rw, "unexpected account", http.StatusBadRequest)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+
+		transactions := []Transaction{event.Data}
+		if err := categorise(transactions); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		now := time.Now()
+		token, err := loadToken(r.Context())
+		accessToken := ""
+		if err == nil {
+			accessToken = token.AccessToken
+		}
+
+		if err := w.deliver(r.Context(), w.checkTransactions(accountID, transactions, now), accessToken, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+}
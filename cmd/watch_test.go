@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWatcher(t *testing.T, rules ...AlertRule) *alertWatcher {
+	t.Helper()
+
+	for i := range rules {
+		if rules[i].Filter != "" {
+			filter, err := compileFilter(rules[i].Filter)
+			if err != nil {
+				t.Fatalf("Failed to compile filter: %v", err)
+			}
+			rules[i].filter = filter
+		}
+	}
+
+	return &alertWatcher{
+		config: &AlertConfig{Rules: rules},
+		state:  &AlertState{Since: map[string]time.Time{}, Sent: map[string]time.Time{}},
+	}
+}
+
+func TestCheckBalanceAlertsOnceUntilRecovered(t *testing.T) {
+	w := newTestWatcher(t, AlertRule{Name: "low", Type: "balance_below", Amount: 10000})
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := w.checkBalance("acc_1", &BalanceResponse{Balance: 5000, Currency: "GBP"}, now)
+	if len(alerts) != 1 || alerts[0].Message != "Balance is £50.00, below £100.00" {
+		t.Fatalf("Expected a low balance alert, got %+v", alerts)
+	}
+
+	if alerts := w.checkBalance("acc_1", &BalanceResponse{Balance: 4000, Currency: "GBP"}, now); len(alerts) != 0 {
+		t.Errorf("Expected the alert not to repeat, got %+v", alerts)
+	}
+
+	// Recovering re-arms the alert
+	w.checkBalance("acc_1", &BalanceResponse{Balance: 20000, Currency: "GBP"}, now)
+	if alerts := w.checkBalance("acc_1", &BalanceResponse{Balance: 4000, Currency: "GBP"}, now); len(alerts) != 1 {
+		t.Errorf("Expected the alert to fire again after recovering, got %+v", alerts)
+	}
+}
+
+func TestCheckTransactions(t *testing.T) {
+	w := newTestWatcher(t,
+		AlertRule{Name: "big", Type: "transaction_over", Amount: 50000, Filter: `category != "bills"`},
+		AlertRule{Name: "euro", Type: "transaction_over", Amount: 0, Currency: "EUR"},
+	)
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	transactions := []Transaction{
+		{ID: "tx_1", Created: "2024-04-01T09:00:00Z", Amount: -60000, Currency: "GBP", Category: "shopping", Description: "Apple"},
+		{ID: "tx_2", Created: "2024-04-01T10:00:00Z", Amount: -90000, Currency: "GBP", Category: "bills", Description: "Rent"},
+		{ID: "tx_3", Created: "2024-04-01T11:00:00Z", Amount: -70000, Currency: "GBP", Category: "shopping", DeclineReason: "INSUFFICIENT_FUNDS"},
+		{ID: "tx_4", Created: "2024-04-01T11:30:00Z", Amount: -500, Currency: "EUR", Description: "Cafe"},
+		{ID: "tx_5", Created: "2024-04-01T11:45:00Z", Amount: 80000, Currency: "GBP", Description: "Salary"},
+	}
+
+	alerts := w.checkTransactions("acc_1", transactions, now)
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %+v", alerts)
+	}
+	if alerts[0].TransactionID != "tx_1" || alerts[0].Message != "£600.00 spent at Apple" {
+		t.Errorf("Unexpected alert: %+v", alerts[0])
+	}
+	if alerts[1].TransactionID != "tx_4" || alerts[1].Rule != "euro" {
+		t.Errorf("Unexpected alert: %+v", alerts[1])
+	}
+
+	w.advanceSince("acc_1", transactions)
+	if got := w.state.Since["acc_1"]; !got.Equal(time.Date(2024, 4, 1, 11, 45, 0, 0, time.UTC)) {
+		t.Errorf("Expected since to advance to the newest transaction, got %v", got)
+	}
+
+	if alerts := w.checkTransactions("acc_1", transactions, now); len(alerts) != 0 {
+		t.Errorf("Expected no repeated alerts, got %+v", alerts)
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	w := newTestWatcher(t, AlertRule{Name: "big", Type: "transaction_over", Amount: 50000})
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	w.state.Since["acc_1"] = since
+	handler := w.webhookHandler("acc_1", "s3cret")
+
+	post := func(path, accountID, id string) int {
+		body := `{"type": "transaction.created", "data": {"id": "` + id + `", "account_id": "` + accountID + `",
+			"created": "2099-01-01T00:00:00Z", "amount": -60000, "currency": "GBP", "description": "Apple"}}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := post("/", "acc_1", "tx_forged"); code != http.StatusNotFound {
+		t.Errorf("Expected webhooks without the secret to be rejected, got %d", code)
+	}
+	if code := post("/wrong", "acc_1", "tx_forged"); code != http.StatusNotFound {
+		t.Errorf("Expected webhooks with the wrong secret to be rejected, got %d", code)
+	}
+	if code := post("/s3cret", "acc_other", "tx_other"); code != http.StatusBadRequest {
+		t.Errorf("Expected webhooks for another account to be rejected, got %d", code)
+	}
+	if len(w.state.Sent) != 0 {
+		t.Errorf("Expected rejected webhooks not to alert, got %v", w.state.Sent)
+	}
+
+	if code := post("/s3cret", "acc_1", "tx_1"); code != http.StatusOK {
+		t.Fatalf("Expected the webhook to be accepted, got %d", code)
+	}
+	if _, sent := w.state.Sent["big:tx_1"]; !sent {
+		t.Errorf("Expected an alert for the webhook transaction, got %v", w.state.Sent)
+	}
+	if got := w.state.Since["acc_1"]; !got.Equal(since) {
+		t.Errorf("Expected webhooks not to move since, got %v", got)
+	}
+}
+
+func TestDeliverRetriesFailedAlerts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var received []string
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("Failed to decode alert: %v", err)
+		}
+		received = append(received, alert.TransactionID)
+	}))
+	defer server.Close()
+
+	w := newTestWatcher(t, AlertRule{Name: "big", Type: "transaction_over", Amount: 50000})
+	w.config.Notifiers = []Notifier{{Type: "webhook", URL: server.URL}}
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	alerts := w.checkTransactions("acc_1", []Transaction{
+		{ID: "tx_1", Created: "2024-04-01T09:00:00Z", Amount: -60000, Currency: "GBP"},
+	}, now)
+	if err := w.deliver(context.Background(), alerts, "", now); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if len(w.state.Failed) != 1 {
+		t.Fatalf("Expected the undelivered alert to be kept, got %+v", w.state.Failed)
+	}
+
+	failing = false
+	if err := w.deliver(context.Background(), nil, "", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if len(received) != 1 || received[0] != "tx_1" {
+		t.Errorf("Expected the alert to be retried, got %v", received)
+	}
+	if len(w.state.Failed) != 0 {
+		t.Errorf("Expected no failed alerts after delivery, got %+v", w.state.Failed)
+	}
+}
+
+func TestNotifierValidate(t *testing.T) {
+	tests := []struct {
+		notifier Notifier
+		valid    bool
+	}{
+		{Notifier{Type: "stdout"}, true},
+		{Notifier{Type: "exec", Command: []string{"notify-send"}}, true},
+		{Notifier{Type: "exec"}, false},
+		{Notifier{Type: "webhook", URL: "https://example.com/alerts"}, true},
+		{Notifier{Type: "webhook"}, false},
+		{Notifier{Type: "feed", ImageURL: "https://example.com/icon.png"}, true},
+		{Notifier{Type: "feed"}, false},
+		{Notifier{Type: "email"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.notifier.validate(); (err == nil) != tt.valid {
+			t.Errorf("validate(%+v): expected valid %v, got %v", tt.notifier, tt.valid, err)
+		}
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var received Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode alert: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	alert := Alert{Rule: "big", Type: "transaction_over", Message: "£600.00 spent at Apple", TransactionID: "tx_1"}
+	if err := (Notifier{Type: "webhook", URL: server.URL}).notify(context.Background(), alert, ""); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+
+	if received.Rule != "big" || received.TransactionID != "tx_1" {
+		t.Errorf("Unexpected alert received: %+v", received)
+	}
+}
@@ -2,9 +2,13 @@ module github.com/vibe-chung/go-monzo
 
 go 1.25.4
 
-require github.com/spf13/cobra v1.8.1
+require (
+	github.com/spf13/cobra v1.8.1
+	golang.org/x/term v0.45.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
@@ -0,0 +1,351 @@
+package monzotest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Account is an account served by the mock API
+type Account struct {
+	ID            string  `json:"id"`
+	Description   string  `json:"description"`
+	Created       string  `json:"created"`
+	Type          string  `json:"type"`
+	Currency      string  `json:"currency"`
+	CountryCode   string  `json:"country_code"`
+	Owners        []Owner `json:"owners"`
+	AccountNumber string  `json:"account_number,omitempty"`
+	SortCode      string  `json:"sort_code,omitempty"`
+	BusinessID    string  `json:"business_id,omitempty"`
+	Closed        bool    `json:"closed"`
+}
+
+// Owner is a user who owns an account
+type Owner struct {
+	UserID             string `json:"user_id"`
+	PreferredName      string `json:"preferred_name"`
+	PreferredFirstName string `json:"preferred_first_name"`
+}
+
+// Balance is the balance of an account served by the mock API
+type Balance struct {
+	Balance                         int64  `json:"balance"`
+	TotalBalance                    int64  `json:"total_balance"`
+	BalanceIncludingFlexibleSavings int64  `json:"balance_including_flexible_savings"`
+	Currency                        string `json:"currency"`
+	SpendToday                      int64  `json:"spend_today"`
+}
+
+// Pot is a savings pot served by the mock API
+type Pot struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Style            string `json:"style"`
+	Balance          int64  `json:"balance"`
+	Currency         string `json:"currency"`
+	Created          string `json:"created"`
+	Updated          string `json:"updated"`
+	Deleted          bool   `json:"deleted"`
+	CurrentAccountID string `json:"current_account_id"`
+}
+
+// Merchant is the expanded merchant of a transaction
+type Merchant struct {
+	ID       string           `json:"id"`
+	GroupID  string           `json:"group_id"`
+	Name     string           `json:"name"`
+	Logo     string           `json:"logo"`
+	Emoji    string           `json:"emoji,omitempty"`
+	Category string           `json:"category"`
+	Online   bool             `json:"online"`
+	ATM      bool             `json:"atm"`
+	Address  *MerchantAddress `json:"address,omitempty"`
+}
+
+// MerchantAddress is the location of a merchant
+type MerchantAddress struct {
+	Address   string  `json:"address"`
+	City      string  `json:"city"`
+	Region    string  `json:"region"`
+	Postcode  string  `json:"postcode"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Transaction is a transaction served by the mock API
+type Transaction struct {
+	ID                string            `json:"id"`
+	AccountID         string            `json:"account_id"`
+	Created           string            `json:"created"`
+	Description       string            `json:"description"`
+	Amount            int64             `json:"amount"`
+	Currency          string            `json:"currency"`
+	Merchant          *Merchant         `json:"merchant,omitempty"`
+	Notes             string            `json:"notes"`
+	Metadata          map[string]string `json:"metadata"`
+	AccountBalance    int64             `json:"account_balance"`
+	Category          string            `json:"category"`
+	IsLoad            bool              `json:"is_load"`
+	Settled           string            `json:"settled"`
+	LocalAmount       int64             `json:"local_amount"`
+	LocalCurrency     string            `json:"local_currency"`
+	DeclineReason     string            `json:"decline_reason,omitempty"`
+	IncludeInSpending bool              `json:"include_in_spending"`
+	AmountIsPending   bool              `json:"amount_is_pending"`
+	Attachments       []Attachment      `json:"attachments"`
+	Counterparty      *Counterparty     `json:"counterparty,omitempty"`
+	Scheme            string            `json:"scheme"`
+}
+
+// Counterparty is the other party to a transfer
+type Counterparty struct {
+	Name          string `json:"name"`
+	UserID        string `json:"user_id,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
+	SortCode      string `json:"sort_code,omitempty"`
+}
+
+// Attachment is an image attached to a transaction, such as a receipt
+type Attachment struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	ExternalID string `json:"external_id"`
+	FileURL    string `json:"file_url"`
+	FileType   string `json:"file_type"`
+	Created    string `json:"created"`
+}
+
+// Webhook is a webhook registered with the mock API
+type Webhook struct {
+	ID        string `json:"id"`
+	AccountID string `json:"account_id"`
+	URL       string `json:"url"`
+}
+
+// FeedItem is a feed item created through the mock API
+type FeedItem struct {
+	AccountID string            `json:"account_id"`
+	Type      string            `json:"type"`
+	URL       string            `json:"url"`
+	Params    map[string]string `json:"params"`
+}
+
+// Account IDs of the seeded data
+const (
+	PersonalAccountID = "acc_mock_personal"
+	JointAccountID    = "acc_mock_joint"
+	BusinessAccountID = "acc_mock_business"
+	ClosedAccountID   = "acc_mock_closed"
+)
+
+var (
+	tesco    = &Merchant{ID: "merch_tesco", GroupID: "grp_tesco", Name: "Tesco", Emoji: "🛒", Category: "groceries", Address: &MerchantAddress{Address: "1 High Street", City: "London", Postcode: "E1 6AN", Country: "GBR", Latitude: 51.52, Longitude: -0.07}}
+	tescoExp = &Merchant{ID: "merch_tesco_express", GroupID: "grp_tesco", Name: "Tesco Express", Emoji: "🛒", Category: "groceries", Address: &MerchantAddress{Address: "22 Station Road", City: "London", Postcode: "N1 9AL", Country: "GBR", Latitude: 51.53, Longitude: -0.12}}
+	pret     = &Merchant{ID: "merch_pret", GroupID: "grp_pret", Name: "Pret A Manger", Emoji: "☕", Category: "eating_out", Address: &MerchantAddress{Address: "10 Fleet Street", City: "London", Postcode: "EC4Y 1AA", Country: "GBR"}}
+	tfl      = &Merchant{ID: "merch_tfl", GroupID: "grp_tfl", Name: "Transport for London", Emoji: "🚇", Category: "transport"}
+	netflix  = &Merchant{ID: "merch_netflix", GroupID: "grp_netflix", Name: "Netflix", Emoji: "🎬", Category: "entertainment", Online: true}
+	gym      = &Merchant{ID: "merch_gym", GroupID: "grp_gym", Name: "PureGym", Emoji: "🏋️", Category: "personal_care"}
+	landlord = &Merchant{ID: "merch_rent", GroupID: "grp_rent", Name: "Acme Lettings", Emoji: "🏠", Category: "bills"}
+	atm      = &Merchant{ID: "merch_atm", GroupID: "grp_atm", Name: "Cash machine", Emoji: "🏧", Category: "cash", ATM: true}
+	bistro   = &Merchant{ID: "merch_bistro", GroupID: "grp_bistro", Name: "Le Petit Bistro", Emoji: "🍷", Category: "eating_out", Address: &MerchantAddress{City: "Paris", Country: "FRA"}}
+	louvre   = &Merchant{ID: "merch_louvre", GroupID: "grp_louvre", Name: "Musée du Louvre", Emoji: "🖼️", Category: "entertainment", Address: &MerchantAddress{City: "Paris", Country: "FRA"}}
+	amazon   = &Merchant{ID: "merch_amazon", GroupID: "grp_amazon", Name: "Amazon", Emoji: "📦", Category: "shopping", Online: true}
+	energy   = &Merchant{ID: "merch_energy", GroupID: "grp_energy", Name: "Bright Energy", Emoji: "💡", Category: "bills", Online: true}
+	hosting  = &Merchant{ID: "merch_hosting", GroupID: "grp_hosting", Name: "CloudHost", Emoji: "☁️", Category: "general", Online: true}
+)
+
+var (
+	mockUser    = Owner{UserID: UserID, PreferredName: "Alex Mock", PreferredFirstName: "Alex"}
+	mockPartner = Owner{UserID: "user_partner", PreferredName: "Sam Partner", PreferredFirstName: "Sam"}
+)
+
+// seed fills the server with three months of realistic data ending at now
+func (s *Server) seed(now time.Time) {
+	created := now.AddDate(-2, 0, 0).UTC().Format(time.RFC3339)
+
+	s.Accounts = []Account{
+		{ID: PersonalAccountID, Description: "user_mock", Created: created, Type: "uk_retail", Currency: "GBP", CountryCode: "GB", Owners: []Owner{mockUser}, AccountNumber: "12345678", SortCode: "040004"},
+		{ID: JointAccountID, Description: "user_mock user_partner", Created: created, Type: "uk_retail_joint", Currency: "GBP", CountryCode: "GB", Owners: []Owner{mockUser, mockPartner}, AccountNumber: "23456789", SortCode: "040004"},
+		{ID: BusinessAccountID, Description: "Mock Consulting Ltd", Created: created, Type: "uk_business", Currency: "GBP", CountryCode: "GB", Owners: []Owner{mockUser}, AccountNumber: "34567890", SortCode: "040004", BusinessID: "business_mock"},
+		{ID: ClosedAccountID, Description: "user_mock", Created: created, Type: "uk_prepaid", Currency: "GBP", CountryCode: "GB", Owners: []Owner{mockUser}, Closed: true},
+	}
+
+	s.Pots = []Pot{
+		{ID: "pot_mock_holiday", Name: "Holiday", Style: "beach_ball", Balance: 45000, Currency: "GBP", Created: created, Updated: created, CurrentAccountID: PersonalAccountID},
+		{ID: "pot_mock_rainy_day", Name: "Rainy day", Style: "umbrella", Balance: 120000, Currency: "GBP", Created: created, Updated: created, CurrentAccountID: PersonalAccountID},
+		{ID: "pot_mock_old", Name: "Old pot", Style: "piggy_bank", Currency: "GBP", Created: created, Updated: created, CurrentAccountID: PersonalAccountID, Deleted: true},
+		{ID: "pot_mock_bills", Name: "Bills", Style: "yellow", Balance: 30000, Currency: "GBP", Created: created, Updated: created, CurrentAccountID: JointAccountID},
+	}
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -90)
+	var personal, joint, business []Transaction
+	add := func(txs *[]Transaction, day time.Time, hour int, description string, amount int64, merchant *Merchant) *Transaction {
+		at := day.Add(time.Duration(hour) * time.Hour)
+		if at.After(now) {
+			return nil
+		}
+		category, scheme := "general", "payport_faster_payments"
+		if merchant != nil {
+			category, scheme = merchant.Category, "mastercard"
+		}
+		*txs = append(*txs, Transaction{
+			Created:           at.Format(time.RFC3339Nano),
+			Description:       description,
+			Amount:            amount,
+			Currency:          "GBP",
+			Merchant:          merchant,
+			Metadata:          map[string]string{},
+			Attachments:       []Attachment{},
+			Category:          category,
+			IsLoad:            amount > 0 && merchant == nil,
+			Settled:           at.Add(24 * time.Hour).Format(time.RFC3339Nano),
+			LocalAmount:       amount,
+			LocalCurrency:     "GBP",
+			IncludeInSpending: amount < 0,
+			Scheme:            scheme,
+		})
+		return &(*txs)[len(*txs)-1]
+	}
+
+	for day := start; !day.After(now); day = day.AddDate(0, 0, 1) {
+		n := int(day.Sub(start).Hours() / 24)
+
+		if day.Day() == 25 {
+			salary := add(&personal, day, 6, "ACME LTD SALARY", 320000, nil)
+			if salary != nil {
+				salary.Category = "income"
+				salary.Scheme = "bacs"
+			}
+		}
+		if day.Day() == 1 {
+			if tx := add(&joint, day, 8, "ACME LETTINGS RENT", -150000, landlord); tx != nil {
+				tx.Scheme = "bacs"
+			}
+			if tx := add(&personal, day, 9, "Transfer to joint account", -90000, nil); tx != nil {
+				tx.Category = "transfers"
+				tx.IncludeInSpending = false
+			}
+			if tx := add(&joint, day, 9, "Transfer from personal account", 90000, nil); tx != nil {
+				tx.Category = "transfers"
+			}
+		}
+		if day.Day() == 28 {
+			if tx := add(&business, day, 10, "CLIENT CO INVOICE", 450000, nil); tx != nil {
+				tx.Category = "income"
+			}
+		}
+		if day.Day() == 2 {
+			add(&business, day, 4, "CLOUDHOST", -4800, hosting)
+		}
+		if day.Day() == 12 {
+			add(&personal, day, 3, "NETFLIX.COM", -1099, netflix)
+		}
+		if day.Day() == 5 {
+			add(&personal, day, 7, "PUREGYM", -2499, gym)
+			if tx := add(&joint, day, 7, "BRIGHT ENERGY", -8500-int64(n%3)*250, energy); tx != nil {
+				tx.Scheme = "bacs"
+			}
+		}
+		if day.Weekday() == time.Saturday {
+			add(&personal, day, 11, "TESCO STORES", -4200-int64(n%5)*310, tesco)
+		}
+		if day.Weekday() == time.Wednesday && n%3 == 0 {
+			add(&personal, day, 18, "TESCO EXPRESS", -1250-int64(n%4)*175, tescoExp)
+		}
+		if day.Weekday() >= time.Monday && day.Weekday() <= time.Friday {
+			add(&personal, day, 8, "TFL TRAVEL CH", -280, tfl)
+			if n%2 == 0 {
+				add(&personal, day, 12, "PRET A MANGER", -450-int64(n%3)*100, pret)
+			}
+		}
+		if n%17 == 3 {
+			if tx := add(&personal, day, 19, "AMAZON.CO.UK", -1999-int64(n%4)*1250, amazon); tx != nil {
+				tx.Attachments = []Attachment{{
+					ID:       fmt.Sprintf("attach_mock_%03d", n),
+					UserID:   UserID,
+					FileURL:  fmt.Sprintf("https://example.com/receipts/%03d.jpg", n),
+					FileType: "image/jpeg",
+					Created:  tx.Created,
+				}}
+			}
+		}
+		if n%23 == 10 {
+			add(&personal, day, 14, "CASH WITHDRAWAL", -5000, atm)
+		}
+		if n%31 == 15 {
+			if tx := add(&personal, day, 22, "CASH WITHDRAWAL", -10000, atm); tx != nil {
+				tx.DeclineReason = "INVALID_PIN"
+				tx.Settled = ""
+			}
+		}
+		if n%14 == 6 {
+			if tx := add(&personal, day, 18, "pot_mock_holiday", -5000, nil); tx != nil {
+				tx.Metadata["pot_id"] = "pot_mock_holiday"
+				tx.Category = "savings"
+				tx.Scheme = "uk_retail_pot"
+				tx.IncludeInSpending = false
+			}
+		}
+		if n%45 == 20 {
+			if tx := add(&personal, day, 20, "ONLINE STORE", -25000, amazon); tx != nil {
+				tx.DeclineReason = "INSUFFICIENT_FUNDS"
+				tx.Settled = ""
+			}
+		}
+		// A long weekend in Paris
+		if n >= 40 && n <= 42 {
+			if tx := add(&personal, day, 13, "LE PETIT BISTRO", -3845-int64(n-40)*520, bistro); tx != nil {
+				tx.LocalAmount = -4500 - int64(n-40)*600
+				tx.LocalCurrency = "EUR"
+			}
+			if n == 41 {
+				if tx := add(&personal, day, 10, "MUSEE DU LOUVRE", -1880, louvre); tx != nil {
+					tx.LocalAmount = -2200
+					tx.LocalCurrency = "EUR"
+				}
+			}
+		}
+		// A friend pays back their half of the first dinner in Paris
+		if n == 44 {
+			if tx := add(&personal, day, 17, "Paris dinner", 1922, nil); tx != nil {
+				tx.Category = "transfers"
+				tx.Counterparty = &Counterparty{Name: "Jamie Friend", AccountNumber: "87654321", SortCode: "200000"}
+			}
+		}
+	}
+
+	// The latest card payment hasn't settled yet
+	if len(personal) > 0 {
+		latest := add(&personal, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), 0, "PRET A MANGER", -395, pret)
+		if latest != nil {
+			latest.Settled = ""
+			latest.AmountIsPending = true
+		}
+	}
+
+	s.Transactions = nil
+	s.addSeeded(PersonalAccountID, personal, 250000)
+	s.addSeeded(JointAccountID, joint, 180000)
+	s.addSeeded(BusinessAccountID, business, 1200000)
+}
+
+// addSeeded assigns IDs and running balances to seeded transactions
+func (s *Server) addSeeded(accountID string, transactions []Transaction, openingBalance int64) {
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Created < transactions[j].Created })
+
+	balance := openingBalance
+	for i := range transactions {
+		tx := &transactions[i]
+		tx.ID = fmt.Sprintf("tx_mock_%s_%04d", accountID[len("acc_mock_"):], i+1)
+		tx.AccountID = accountID
+		if tx.DeclineReason == "" {
+			balance += tx.Amount
+		}
+		tx.AccountBalance = balance
+	}
+
+	s.Transactions = append(s.Transactions, transactions...)
+	s.balances[accountID] = balance
+}
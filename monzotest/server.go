@@ -0,0 +1,643 @@
+// Package monzotest provides a mock of the Monzo API seeded with fake data,
+// for tests and for trying out go-monzo without real credentials.
+//
+// The mock implements OAuth, accounts, balance, pots, transactions, feed
+// items and webhooks:
+//
+//	server := httptest.NewServer(monzotest.NewServer())
+//	defer server.Close()
+//
+// Requests are authorised with AccessToken, or with any token issued by the
+// mock's OAuth endpoints for ClientID and ClientSecret.
+package monzotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the mock API
+const (
+	ClientID     = "mock_client_id"
+	ClientSecret = "mock_client_secret"
+	UserID       = "user_mock"
+
+	// AccessToken and RefreshToken are always valid
+	AccessToken  = "mock_access_token"
+	RefreshToken = "mock_refresh_token"
+)
+
+// tokenLifetime is the expires_in of issued access tokens, as used by Monzo
+const tokenLifetime = 6 * time.Hour
+
+// maxPageSize is the most transactions returned in one page, and the page
+// size when a request doesn't set limit
+const maxPageSize = 100
+
+// Server is a mock Monzo API. Its exported fields hold the data it serves
+// and can be changed before the server starts handling requests.
+type Server struct {
+	Accounts     []Account
+	Pots         []Pot
+	Transactions []Transaction // Oldest first
+	Webhooks     []Webhook
+	FeedItems    []FeedItem
+
+	mu            sync.Mutex
+	mux           *http.ServeMux
+	now           func() time.Time
+	balances      map[string]int64
+	accessTokens  map[string]bool
+	refreshTokens map[string]bool
+	codes         map[string]bool
+	dedupeIDs     map[string]bool
+	nextID        int
+}
+
+// NewServer returns a mock API seeded with three months of data ending now
+func NewServer() *Server {
+	s := NewServerAt(time.Now())
+	s.now = time.Now
+	return s
+}
+
+// NewServerAt returns a mock API seeded with three months of data ending at
+// now, with its clock stopped at now so tests can rely on the data served
+func NewServerAt(now time.Time) *Server {
+	s := &Server{
+		now:           func() time.Time { return now },
+		balances:      make(map[string]int64),
+		accessTokens:  make(map[string]bool),
+		refreshTokens: make(map[string]bool),
+		codes:         make(map[string]bool),
+		dedupeIDs:     make(map[string]bool),
+	}
+	s.seed(now)
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /{$}", s.handleAuthorize)
+	s.mux.HandleFunc("POST /oauth2/token", s.handleToken)
+	s.mux.HandleFunc("GET /ping/whoami", s.authorised(s.handleWhoAmI))
+	s.mux.HandleFunc("GET /accounts", s.authorised(s.handleAccounts))
+	s.mux.HandleFunc("GET /balance", s.authorised(s.handleBalance))
+	s.mux.HandleFunc("GET /pots", s.authorised(s.handlePots))
+	s.mux.HandleFunc("PUT /pots/{id}/deposit", s.authorised(s.handlePotDeposit))
+	s.mux.HandleFunc("PUT /pots/{id}/withdraw", s.authorised(s.handlePotWithdraw))
+	s.mux.HandleFunc("GET /transactions", s.authorised(s.handleTransactions))
+	s.mux.HandleFunc("GET /transactions/{id}", s.authorised(s.handleTransaction))
+	s.mux.HandleFunc("PATCH /transactions/{id}", s.authorised(s.handleAnnotate))
+	s.mux.HandleFunc("POST /feed", s.authorised(s.handleFeed))
+	s.mux.HandleFunc("GET /webhooks", s.authorised(s.handleListWebhooks))
+	s.mux.HandleFunc("POST /webhooks", s.authorised(s.handleRegisterWebhook))
+	s.mux.HandleFunc("DELETE /webhooks/{id}", s.authorised(s.handleDeleteWebhook))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// AddTransaction records a new transaction on an account, updating its
+// balance and sending transaction.created to the account's webhooks. The ID
+// and running balance are filled in if not set.
+func (s *Server) AddTransaction(tx Transaction) Transaction {
+	s.mu.Lock()
+	tx = s.addTransaction(tx)
+	webhooks := s.webhooksFor(tx.AccountID)
+	s.mu.Unlock()
+
+	for _, webhook := range webhooks {
+		go deliverWebhook(webhook.URL, tx)
+	}
+	return tx
+}
+
+// addTransaction must be called with s.mu held
+func (s *Server) addTransaction(tx Transaction) Transaction {
+	if tx.ID == "" {
+		tx.ID = s.newID("tx")
+	}
+	if tx.Created == "" {
+		tx.Created = s.now().UTC().Format(time.RFC3339Nano)
+	}
+	if tx.Currency == "" {
+		tx.Currency = "GBP"
+	}
+	if tx.Metadata == nil {
+		tx.Metadata = map[string]string{}
+	}
+	if tx.DeclineReason == "" {
+		s.balances[tx.AccountID] += tx.Amount
+	}
+	tx.AccountBalance = s.balances[tx.AccountID]
+
+	s.Transactions = append(s.Transactions, tx)
+	return tx
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s_mock_%06d", prefix, s.nextID)
+}
+
+func (s *Server) webhooksFor(accountID string) []Webhook {
+	var webhooks []Webhook
+	for _, webhook := range s.Webhooks {
+		if webhook.AccountID == accountID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks
+}
+
+func deliverWebhook(webhookURL string, tx Transaction) {
+	data, err := json.Marshal(map[string]interface{}{"type": "transaction.created", "data": tx})
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// writeJSON writes a successful JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format used by the Monzo API
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+// authorised rejects requests without a valid access token
+func (s *Server) authorised(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		valid := ok && (token == AccessToken || s.accessTokens[token])
+		s.mu.Unlock()
+
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_access_token", "Access token is invalid")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAuthorize approves every authorisation request and redirects back
+// to the client with a code
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != ClientID {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.client_id", "Unknown client ID")
+		return
+	}
+
+	redirectURL, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURL.Scheme == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.redirect_uri", "Invalid redirect URI")
+		return
+	}
+
+	s.mu.Lock()
+	code := s.newID("code")
+	s.codes[code] = true
+	s.mu.Unlock()
+
+	params := redirectURL.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirectURL.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid form")
+		return
+	}
+
+	if r.PostForm.Get("client_id") != ClientID || r.PostForm.Get("client_secret") != ClientSecret {
+		writeError(w, http.StatusUnauthorized, "unauthorized.bad_client_credentials", "Client credentials are invalid")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if !s.codes[code] {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_authorization_code", "Authorization code is invalid")
+			return
+		}
+		delete(s.codes, code)
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		if refreshToken != RefreshToken && !s.refreshTokens[refreshToken] {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_refresh_token", "Refresh token is invalid")
+			return
+		}
+		delete(s.refreshTokens, refreshToken)
+	default:
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.grant_type", "Unsupported grant type")
+		return
+	}
+
+	accessToken, refreshToken := s.newID("access"), s.newID("refresh")
+	s.accessTokens[accessToken] = true
+	s.refreshTokens[refreshToken] = true
+
+	writeJSON(w, map[string]interface{}{
+		"access_token":  accessToken,
+		"client_id":     ClientID,
+		"expires_in":    int(tokenLifetime.Seconds()),
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"user_id":       UserID,
+	})
+}
+
+func (s *Server) handleWhoAmI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"authenticated": true, "client_id": ClientID, "user_id": UserID})
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountType := r.URL.Query().Get("account_type")
+	accounts := []Account{}
+	for _, account := range s.Accounts {
+		if accountType == "" || account.Type == accountType {
+			accounts = append(accounts, account)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"accounts": accounts})
+}
+
+// account returns the account with an ID. s.mu must be held.
+func (s *Server) account(id string) *Account {
+	for i := range s.Accounts {
+		if s.Accounts[i].ID == id {
+			return &s.Accounts[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := r.URL.Query().Get("account_id")
+	account := s.account(accountID)
+	if account == nil {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	balance := s.balances[accountID]
+	total := balance
+	for _, pot := range s.Pots {
+		if pot.CurrentAccountID == accountID && !pot.Deleted {
+			total += pot.Balance
+		}
+	}
+
+	today := s.now().UTC().Format("2006-01-02")
+	var spendToday int64
+	for _, tx := range s.Transactions {
+		if tx.AccountID == accountID && tx.Amount < 0 && tx.IncludeInSpending && tx.DeclineReason == "" && strings.HasPrefix(tx.Created, today) {
+			spendToday += tx.Amount
+		}
+	}
+
+	writeJSON(w, Balance{
+		Balance:                         balance,
+		TotalBalance:                    total,
+		BalanceIncludingFlexibleSavings: total,
+		Currency:                        account.Currency,
+		SpendToday:                      spendToday,
+	})
+}
+
+func (s *Server) handlePots(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := r.URL.Query().Get("current_account_id")
+	if s.account(accountID) == nil {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	pots := []Pot{}
+	for _, pot := range s.Pots {
+		if pot.CurrentAccountID == accountID {
+			pots = append(pots, pot)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"pots": pots})
+}
+
+func (s *Server) handlePotDeposit(w http.ResponseWriter, r *http.Request) {
+	s.movePotMoney(w, r, "source_account_id", 1)
+}
+
+func (s *Server) handlePotWithdraw(w http.ResponseWriter, r *http.Request) {
+	s.movePotMoney(w, r, "destination_account_id", -1)
+}
+
+// movePotMoney moves money into (direction 1) or out of (direction -1) a pot
+func (s *Server) movePotMoney(w http.ResponseWriter, r *http.Request, accountParam string, direction int64) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid form")
+		return
+	}
+
+	amount, err := strconv.ParseInt(r.PostForm.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.amount", "Amount must be a positive integer")
+		return
+	}
+
+	dedupeID := r.PostForm.Get("dedupe_id")
+	if dedupeID == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.missing_param.dedupe_id", "dedupe_id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pot *Pot
+	for i := range s.Pots {
+		if s.Pots[i].ID == r.PathValue("id") && !s.Pots[i].Deleted {
+			pot = &s.Pots[i]
+		}
+	}
+	if pot == nil {
+		writeError(w, http.StatusNotFound, "not_found.pot", "Pot not found")
+		return
+	}
+
+	if r.PostForm.Get(accountParam) != pot.CurrentAccountID {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param."+accountParam, "The pot doesn't belong to this account")
+		return
+	}
+
+	key := pot.ID + ":" + dedupeID
+	if s.dedupeIDs[key] {
+		writeJSON(w, pot)
+		return
+	}
+
+	if direction > 0 && s.balances[pot.CurrentAccountID] < amount || direction < 0 && pot.Balance < amount {
+		writeError(w, http.StatusBadRequest, "bad_request.insufficient_funds", "Insufficient funds")
+		return
+	}
+
+	s.dedupeIDs[key] = true
+	pot.Balance += direction * amount
+	pot.Updated = s.now().UTC().Format(time.RFC3339)
+	s.addTransaction(Transaction{
+		AccountID:     pot.CurrentAccountID,
+		Description:   pot.ID,
+		Amount:        -direction * amount,
+		Currency:      pot.Currency,
+		Metadata:      map[string]string{"pot_id": pot.ID},
+		Category:      "savings",
+		Settled:       s.now().UTC().Format(time.RFC3339Nano),
+		LocalAmount:   -direction * amount,
+		LocalCurrency: pot.Currency,
+		Scheme:        "uk_retail_pot",
+	})
+
+	writeJSON(w, pot)
+}
+
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := maxPageSize
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			writeError(w, http.StatusBadRequest, "bad_request.bad_param.limit", "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	// since is either a time or the ID of the transaction to list after
+	var since, before time.Time
+	sinceID := ""
+	if value := query.Get("since"); value != "" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			sinceID = value
+		}
+		since = t
+	}
+	if value := query.Get("before"); value != "" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request.bad_param.before", "before must be an RFC 3339 time")
+			return
+		}
+		before = t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accountID := query.Get("account_id")
+	if s.account(accountID) == nil {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	transactions := []Transaction{}
+	afterSinceID := sinceID == ""
+	for _, tx := range s.Transactions {
+		if tx.AccountID != accountID {
+			continue
+		}
+		if !afterSinceID {
+			afterSinceID = tx.ID == sinceID
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339Nano, tx.Created)
+		if !since.IsZero() && created.Before(since) || !before.IsZero() && !created.Before(before) {
+			continue
+		}
+		transactions = append(transactions, tx)
+		if len(transactions) == limit {
+			break
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{"transactions": transactions})
+}
+
+// transaction returns the transaction with an ID. s.mu must be held.
+func (s *Server) transaction(id string) *Transaction {
+	for i := range s.Transactions {
+		if s.Transactions[i].ID == id {
+			return &s.Transactions[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.transaction(r.PathValue("id"))
+	if tx == nil {
+		writeError(w, http.StatusNotFound, "not_found.transaction", "Transaction not found")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"transaction": tx})
+}
+
+func (s *Server) handleAnnotate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid form")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.transaction(r.PathValue("id"))
+	if tx == nil {
+		writeError(w, http.StatusNotFound, "not_found.transaction", "Transaction not found")
+		return
+	}
+
+	for param, values := range r.PostForm {
+		key, ok := strings.CutPrefix(param, "metadata[")
+		if !ok || !strings.HasSuffix(key, "]") || len(values) == 0 {
+			continue
+		}
+		key = strings.TrimSuffix(key, "]")
+
+		if values[0] == "" {
+			delete(tx.Metadata, key)
+		} else {
+			tx.Metadata[key] = values[0]
+		}
+		if key == "notes" {
+			tx.Notes = values[0]
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{"transaction": tx})
+}
+
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid form")
+		return
+	}
+
+	item := FeedItem{
+		AccountID: r.PostForm.Get("account_id"),
+		Type:      r.PostForm.Get("type"),
+		URL:       r.PostForm.Get("url"),
+		Params:    map[string]string{},
+	}
+	for param, values := range r.PostForm {
+		if key, ok := strings.CutPrefix(param, "params["); ok && len(values) > 0 {
+			item.Params[strings.TrimSuffix(key, "]")] = values[0]
+		}
+	}
+
+	if item.Type != "basic" || item.Params["title"] == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param", "A basic feed item with a title is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.account(item.AccountID) == nil {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+
+	s.FeedItems = append(s.FeedItems, item)
+	writeJSON(w, map[string]interface{}{})
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhooks := s.webhooksFor(r.URL.Query().Get("account_id"))
+	if webhooks == nil {
+		webhooks = []Webhook{}
+	}
+	writeJSON(w, map[string]interface{}{"webhooks": webhooks})
+}
+
+func (s *Server) handleRegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid form")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook := Webhook{AccountID: r.PostForm.Get("account_id"), URL: r.PostForm.Get("url")}
+	if s.account(webhook.AccountID) == nil {
+		writeError(w, http.StatusNotFound, "not_found.account", "Account not found")
+		return
+	}
+	if webhook.URL == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.missing_param.url", "url is required")
+		return
+	}
+
+	webhook.ID = s.newID("webhook")
+	s.Webhooks = append(s.Webhooks, webhook)
+	writeJSON(w, map[string]interface{}{"webhook": webhook})
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, webhook := range s.Webhooks {
+		if webhook.ID == r.PathValue("id") {
+			s.Webhooks = append(s.Webhooks[:i], s.Webhooks[i+1:]...)
+			writeJSON(w, map[string]interface{}{})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found.webhook", "Webhook not found")
+}
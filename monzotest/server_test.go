@@ -0,0 +1,186 @@
+package monzotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// get performs an authorised GET against the mock and decodes the response
+func get(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestUnauthorised(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/accounts")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestOAuthFlow(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(server.URL + "/?client_id=" + ClientID + "&redirect_uri=" + url.QueryEscape("http://localhost:8080/callback") + "&state=abc")
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || location.Query().Get("state") != "abc" || location.Query().Get("code") == "" {
+		t.Fatalf("Unexpected redirect: %s", resp.Header.Get("Location"))
+	}
+
+	exchange := func(form url.Values) (int, map[string]interface{}) {
+		form.Set("client_id", ClientID)
+		form.Set("client_secret", ClientSecret)
+		resp, err := http.PostForm(server.URL+"/oauth2/token", form)
+		if err != nil {
+			t.Fatalf("Token request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	code := location.Query().Get("code")
+	status, token := exchange(url.Values{"grant_type": {"authorization_code"}, "code": {code}})
+	if status != http.StatusOK || token["access_token"] == "" {
+		t.Fatalf("Expected a token, got %d %v", status, token)
+	}
+
+	// Codes can only be used once
+	if status, _ := exchange(url.Values{"grant_type": {"authorization_code"}, "code": {code}}); status != http.StatusUnauthorized {
+		t.Errorf("Expected reused code to be rejected, got %d", status)
+	}
+
+	// The issued access token is accepted
+	req, _ := http.NewRequest("GET", server.URL+"/ping/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+token["access_token"].(string))
+	whoami, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	whoami.Body.Close()
+	if whoami.StatusCode != http.StatusOK {
+		t.Errorf("Expected issued token to be accepted, got %d", whoami.StatusCode)
+	}
+
+	status, refreshed := exchange(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token["refresh_token"].(string)}})
+	if status != http.StatusOK || refreshed["access_token"] == token["access_token"] {
+		t.Errorf("Expected a new token, got %d %v", status, refreshed)
+	}
+}
+
+func TestTransactionsPagination(t *testing.T) {
+	mock := NewServerAt(time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC))
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	total := 0
+	for _, tx := range mock.Transactions {
+		if tx.AccountID == PersonalAccountID {
+			total++
+		}
+	}
+	if total <= maxPageSize {
+		t.Fatalf("Expected more than a page of transactions, got %d", total)
+	}
+
+	// Requests without a limit get one page, like the API
+	var first struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	get(t, server, "/transactions?account_id="+PersonalAccountID, &first)
+	if len(first.Transactions) != maxPageSize {
+		t.Errorf("Expected a page of %d transactions without a limit, got %d", maxPageSize, len(first.Transactions))
+	}
+
+	var pages []Transaction
+	since := ""
+	for {
+		var page struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+		get(t, server, "/transactions?limit=100&account_id="+PersonalAccountID+"&since="+since, &page)
+		if len(page.Transactions) == 0 {
+			break
+		}
+		pages = append(pages, page.Transactions...)
+		since = page.Transactions[len(page.Transactions)-1].ID
+	}
+
+	if len(pages) != total {
+		t.Errorf("Expected pagination to return all %d transactions, got %d", total, len(pages))
+	}
+}
+
+func TestPotDepositIsDeduplicated(t *testing.T) {
+	mock := NewServerAt(time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC))
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	deposit := func() {
+		form := url.Values{"source_account_id": {PersonalAccountID}, "amount": {"1000"}, "dedupe_id": {"once"}}
+		req, _ := http.NewRequest("PUT", server.URL+"/pots/pot_mock_holiday/deposit", strings.NewReader(form.Encode()))
+		req.Header.Set("Authorization", "Bearer "+AccessToken)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Deposit failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode)
+		}
+	}
+
+	var before Balance
+	get(t, server, "/balance?account_id="+PersonalAccountID, &before)
+
+	deposit()
+	deposit()
+
+	var after Balance
+	get(t, server, "/balance?account_id="+PersonalAccountID, &after)
+
+	if after.Balance != before.Balance-1000 {
+		t.Errorf("Expected balance to drop by 1000 once, from %d to %d", before.Balance, after.Balance)
+	}
+	if after.TotalBalance != before.TotalBalance {
+		t.Errorf("Expected total balance to be unchanged, got %d and %d", before.TotalBalance, after.TotalBalance)
+	}
+}